@@ -2,6 +2,9 @@
 package hnsw
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -14,6 +17,8 @@ type BatchInserter struct {
 	queue       chan batchTask
 	wg          sync.WaitGroup
 	inserted    atomic.Int64
+	errMu       sync.Mutex
+	errs        []error
 }
 
 type batchTask struct {
@@ -38,8 +43,13 @@ func NewBatchInserter(hnsw *HNSW, batchSize, workerCount int) *BatchInserter {
 
 func (bi *BatchInserter) worker() {
 	for task := range bi.queue {
-		bi.hnsw.Insert(task.id, task.vec)
-		bi.inserted.Add(1)
+		if err := bi.hnsw.Insert(task.id, task.vec); err != nil {
+			bi.errMu.Lock()
+			bi.errs = append(bi.errs, fmt.Errorf("id %d: %w", task.id, err))
+			bi.errMu.Unlock()
+		} else {
+			bi.inserted.Add(1)
+		}
 		bi.wg.Done()
 	}
 }
@@ -55,17 +65,24 @@ func (bi *BatchInserter) Add(id int, vec Vector) {
 	bi.queue <- batchTask{id, vec}
 }
 
-func (bi *BatchInserter) Stop() {
+// Stop waits for queued inserts to finish and returns the errors of any
+// inserts that failed
+func (bi *BatchInserter) Stop() error {
 	close(bi.queue)
 	bi.wg.Wait()
+
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	return errors.Join(bi.errs...)
 }
 
 func (bi *BatchInserter) Inserted() int64 {
 	return bi.inserted.Load()
 }
 
-// BatchInsert adds multiple vectors efficiently
-func (h *HNSW) BatchInsert(vectors map[int]Vector) {
+// BatchInsert adds multiple vectors efficiently. Vectors that fail to insert
+// are skipped and their errors joined into the returned error.
+func (h *HNSW) BatchInsert(vectors map[int]Vector) error {
 	batchSize := 100
 	if len(vectors) < batchSize {
 		batchSize = len(vectors)
@@ -78,12 +95,36 @@ func (h *HNSW) BatchInsert(vectors map[int]Vector) {
 		inserter.Add(id, vec)
 	}
 
-	inserter.Stop()
+	return inserter.Stop()
+}
+
+// BatchSearch performs parallel searches for multiple queries. Queries that
+// fail leave a nil entry in the results and their errors are joined.
+func (h *HNSW) BatchSearch(queries []Vector, k int, config SearchConfig) ([][]int, error) {
+	return batchQuery(queries, config, func(query Vector) ([]int, error) {
+		return h.SearchWithConfig(query, k, config)
+	})
+}
+
+// BatchSearchWithDistances is BatchSearch returning the distance of every hit
+func (h *HNSW) BatchSearchWithDistances(queries []Vector, k int, config SearchConfig) ([][]SearchResult, error) {
+	return batchQuery(queries, config, func(query Vector) ([]SearchResult, error) {
+		return h.SearchWithDistances(query, k, config)
+	})
+}
+
+// BatchSearchContext is BatchSearchWithDistances with a context shared by all
+// queries. Queries cut short by ctx keep their partial results.
+func (h *HNSW) BatchSearchContext(ctx context.Context, queries []Vector, k int, config SearchConfig) ([][]SearchResult, error) {
+	return batchQuery(queries, config, func(query Vector) ([]SearchResult, error) {
+		return h.SearchContext(ctx, query, k, config)
+	})
 }
 
-// BatchSearch performs parallel searches for multiple queries
-func (h *HNSW) BatchSearch(queries []Vector, k int, config SearchConfig) [][]int {
-	results := make([][]int, len(queries))
+// batchQuery runs search for every query on config.WorkerCount workers
+func batchQuery[T any](queries []Vector, config SearchConfig, search func(Vector) (T, error)) ([]T, error) {
+	results := make([]T, len(queries))
+	errs := make([]error, len(queries))
 	var wg sync.WaitGroup
 
 	workerCount := config.WorkerCount
@@ -96,7 +137,10 @@ func (h *HNSW) BatchSearch(queries []Vector, k int, config SearchConfig) [][]int
 	for w := 0; w < workerCount; w++ {
 		go func() {
 			for i := range taskChan {
-				results[i] = h.SearchWithConfig(queries[i], k, config)
+				results[i], errs[i] = search(queries[i])
+				if errs[i] != nil {
+					errs[i] = fmt.Errorf("query %d: %w", i, errs[i])
+				}
 				wg.Done()
 			}
 		}()
@@ -109,38 +153,40 @@ func (h *HNSW) BatchSearch(queries []Vector, k int, config SearchConfig) [][]int
 	close(taskChan)
 
 	wg.Wait()
-	return results
+	return results, errors.Join(errs...)
 }
 
-// BatchDelete removes multiple vectors efficiently
-func (h *HNSW) BatchDelete(ids []int) {
+// BatchDelete removes multiple vectors efficiently. In DeleteRepair mode the
+// graph is repaired once for the whole batch rather than once per ID. IDs
+// that fail to delete have their errors joined into the returned error.
+func (h *HNSW) BatchDelete(ids []int) error {
 	if len(ids) == 0 {
-		return
+		return nil
 	}
 
-	var wg sync.WaitGroup
-	workerCount := runtime.NumCPU()
-
-	// Calculate items per worker
-	itemsPerWorker := (len(ids) + workerCount - 1) / workerCount
-
-	for w := 0; w < workerCount; w++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			start := workerID * itemsPerWorker
-			if start >= len(ids) {
-				return
-			}
-			end := start + itemsPerWorker
-			if end > len(ids) {
-				end = len(ids)
-			}
-			for _, id := range ids[start:end] {
-				h.Delete(id)
-			}
-		}(w)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var errs []error
+	removed := make(map[*Node]bool, len(ids))
+	for _, id := range ids {
+		node, exists := h.Nodes[id]
+		if !exists || h.deletedNodes[id] {
+			errs = append(errs, fmt.Errorf("%w: %d", ErrNotFound, id))
+			continue
+		}
+		if h.deleteMode == DeleteTombstone {
+			h.deletedNodes[id] = true
+			continue
+		}
+		removed[node] = true
+		delete(h.Nodes, id)
 	}
 
-	wg.Wait()
+	if h.deleteMode == DeleteTombstone {
+		h.maybeAutoVacuum()
+	} else if len(removed) > 0 {
+		h.removeNodes(removed)
+	}
+	return errors.Join(errs...)
 }
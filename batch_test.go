@@ -3,6 +3,7 @@
 package hnsw
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -17,7 +18,9 @@ func TestBatchOperations(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		vectors[i] = Vector{float64(i / 10), float64(i % 10)}
 	}
-	h.BatchInsert(vectors)
+	if err := h.BatchInsert(vectors); err != nil {
+		t.Fatalf("BatchInsert: error = %v", err)
+	}
 
 	if len(h.Nodes) != 100 {
 		t.Errorf("BatchInsert: got %d nodes, want 100", len(h.Nodes))
@@ -30,7 +33,10 @@ func TestBatchOperations(t *testing.T) {
 	}
 
 	config := DefaultSearchConfig()
-	results := h.BatchSearch(queries, 5, config)
+	results, err := h.BatchSearch(queries, 5, config)
+	if err != nil {
+		t.Fatalf("BatchSearch: error = %v", err)
+	}
 
 	if len(results) != len(queries) {
 		t.Errorf("BatchSearch: got %d results, want %d", len(results), len(queries))
@@ -41,13 +47,63 @@ func TestBatchOperations(t *testing.T) {
 	for i := range ids {
 		ids[i] = i
 	}
-	h.BatchDelete(ids)
+	if err := h.BatchDelete(ids); err != nil {
+		t.Fatalf("BatchDelete: error = %v", err)
+	}
 
 	for _, id := range ids {
 		if _, exists := h.Nodes[id]; exists {
 			t.Errorf("BatchDelete: node %d still exists", id)
 		}
 	}
+
+	// Unknown and repeated IDs fail without stopping the rest of the batch
+	err = h.BatchDelete([]int{10, 10, 1000, 11})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("BatchDelete: error = %v; want ErrNotFound", err)
+	}
+	if len(h.Nodes) != 88 {
+		t.Errorf("BatchDelete: got %d nodes, want 88", len(h.Nodes))
+	}
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				if h.Nodes[conn.ID] != conn {
+					t.Fatalf("node %d level %d still links to deleted node %d", id, level, conn.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestBatchSearchWithDistances(t *testing.T) {
+	h := New(2, 16, 32, 100, Euclidean)
+	for i := 0; i < 100; i++ {
+		h.Insert(i, Vector{float64(i / 10), float64(i % 10)})
+	}
+
+	queries := []Vector{{0, 0}, {4.4, 4.4}, {1, 2}}
+	results, err := h.BatchSearchWithDistances(queries, 3, DefaultSearchConfig())
+	if err != nil {
+		t.Fatalf("BatchSearchWithDistances: error = %v", err)
+	}
+
+	for i, hits := range results {
+		want, _ := h.SearchWithDistances(queries[i], 3, DefaultSearchConfig())
+		if len(hits) != len(want) {
+			t.Fatalf("query %d: got %d results, want %d", i, len(hits), len(want))
+		}
+		for j := range hits {
+			if hits[j] != want[j] {
+				t.Errorf("query %d result %d: got %v, want %v", i, j, hits[j], want[j])
+			}
+		}
+	}
+
+	_, err = h.BatchSearchWithDistances([]Vector{{1, 1}, {1}}, 3, DefaultSearchConfig())
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("BatchSearchWithDistances: error = %v; want ErrDimensionMismatch", err)
+	}
 }
 
 func TestBatchEuclideanAVX2Correctness(t *testing.T) {
@@ -66,8 +122,8 @@ func TestBatchEuclideanAVX2Correctness(t *testing.T) {
 		query[i] = rand.Float64()
 	}
 
-	avx2Results := BatchEuclidean(query, vectors)
-	fallbackResults := batchEuclideanFallback(query, vectors)
+	avx2Results := BatchEuclideanSquared(query, vectors)
+	fallbackResults := batchEuclideanSquaredFallback(query, vectors)
 
 	for i := range avx2Results {
 		if math.Abs(avx2Results[i]-fallbackResults[i]) > 1e-10 {
@@ -1,33 +1,27 @@
-//go:build amd64
-// +build amd64
-
 // distance.go
 package hnsw
 
-import (
-    "math"
-
-    "golang.org/x/sys/cpu"
-)
-
-var useAVX2 = cpu.X86.HasAVX2
-
-//go:noescape
-func euclideanAVX2(v1, v2 Vector) float64
-
-//go:noescape
-func cosineAVX2(v1, v2 Vector) float64
+import "math"
 
 // Computes Euclidean distance using SIMD when available
 func Euclidean(v1, v2 Vector) float64 {
-    if useAVX2 && len(v1) >= 8 {
-        return euclideanAVX2(v1, v2)
-    }
-    return euclideanFallback(v1, v2)
+    return math.Sqrt(EuclideanSquared(v1, v2))
+}
+
+// Computes squared Euclidean distance using SIMD when available. It orders
+// vectors like Euclidean without paying for the square root, so indexes
+// using Euclidean rank nodes with it internally.
+func EuclideanSquared(v1, v2 Vector) float64 {
+    return euclideanSquared(v1, v2)
 }
 
 // Fallback implementation
 func euclideanFallback(v1, v2 Vector) float64 {
+    return math.Sqrt(euclideanSquaredFallback(v1, v2))
+}
+
+// Fallback implementation
+func euclideanSquaredFallback(v1, v2 Vector) float64 {
     var sum float64
     for i := 0; i < len(v1); i += 4 {
         if i+4 <= len(v1) {
@@ -43,15 +37,12 @@ func euclideanFallback(v1, v2 Vector) float64 {
             }
         }
     }
-    return math.Sqrt(sum)
+    return sum
 }
 
 // Computes cosine distance using SIMD when available
 func Cosine(v1, v2 Vector) float64 {
-    if useAVX2 && len(v1) >= 8 {
-        return cosineAVX2(v1, v2)
-    }
-    return cosineFallback(v1, v2)
+    return cosine(v1, v2)
 }
 
 // Fallback implementation
@@ -75,3 +66,26 @@ func cosineFallback(v1, v2 Vector) float64 {
     }
     return 1 - dot/math.Sqrt(norm1*norm2)
 }
+
+// Computes the inner product distance, the negated dot product, so that
+// vectors with larger dot products are closer. Use it for maximum inner
+// product search; unlike Cosine it keeps the norm signal.
+func InnerProduct(v1, v2 Vector) float64 {
+    return -dot(v1, v2)
+}
+
+// Fallback implementation
+func dotFallback(v1, v2 Vector) float64 {
+    var sum float64
+    for i := 0; i < len(v1); i += 4 {
+        if i+4 <= len(v1) {
+            sum += v1[i]*v2[i] + v1[i+1]*v2[i+1] +
+                v1[i+2]*v2[i+2] + v1[i+3]*v2[i+3]
+        } else {
+            for j := i; j < len(v1); j++ {
+                sum += v1[j] * v2[j]
+            }
+        }
+    }
+    return sum
+}
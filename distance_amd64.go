@@ -0,0 +1,95 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+// distance_amd64.go
+package hnsw
+
+import (
+    "math"
+
+    "golang.org/x/sys/cpu"
+)
+
+var useAVX2 = cpu.X86.HasAVX2
+
+//go:noescape
+func euclideanSquaredAVX2(v1, v2 Vector) float64
+
+//go:noescape
+func cosineAVX2(v1, v2 Vector) float64
+
+//go:noescape
+func dotAVX2(v1, v2 Vector) float64
+
+//go:noescape
+func batchEuclideanAVX2(query Vector, vectors []Vector, results []float64)
+
+// batchEuclideanSquaredAVX2Flat calculates squared distances between query vector and multiple vectors
+//
+//go:noescape
+func batchEuclideanSquaredAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64)
+
+// Vectors shorter than 8 components aren't worth the AVX2 kernel setup
+func euclideanSquared(v1, v2 Vector) float64 {
+    if useAVX2 && len(v1) >= 8 {
+        return euclideanSquaredAVX2(v1, v2)
+    }
+    return euclideanSquaredFallback(v1, v2)
+}
+
+func cosine(v1, v2 Vector) float64 {
+    if useAVX2 && len(v1) >= 8 {
+        return cosineAVX2(v1, v2)
+    }
+    return cosineFallback(v1, v2)
+}
+
+func dot(v1, v2 Vector) float64 {
+    if useAVX2 && len(v1) >= 8 {
+        return dotAVX2(v1, v2)
+    }
+    return dotFallback(v1, v2)
+}
+
+// batchEuclideanSquared flattens vectors into contiguous memory for the
+// AVX2 kernel
+func batchEuclideanSquared(query Vector, vectors []Vector) []float64 {
+    dim := len(query)
+    if !useAVX2 || dim < 4 {
+        return batchEuclideanSquaredFallback(query, vectors)
+    }
+
+    flatData := make([]float64, len(vectors)*dim)
+    for i, vec := range vectors {
+        copy(flatData[i*dim:], vec)
+    }
+    results := make([]float64, len(vectors))
+    batchEuclideanSquaredAVX2Flat(query, flatData, dim, results)
+    return results
+}
+
+// BatchEuclideanAVX2 calculates distances between query vector and multiple
+// vectors. Without AVX2 support it falls back to Go.
+func BatchEuclideanAVX2(query Vector, vectors []Vector, results []float64) {
+    if !useAVX2 {
+        batchEuclideanFallback(query, vectors, results)
+        return
+    }
+    batchEuclideanAVX2(query, vectors, results)
+}
+
+// BatchEuclideanAVX2Flat calculates distances between query vector and
+// multiple vectors. Without AVX2 support it falls back to Go.
+func BatchEuclideanAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64) {
+    if dim <= 0 {
+        return
+    }
+    if !useAVX2 {
+        batchEuclideanFlatFallback(query, flatVectors, dim, results)
+        return
+    }
+    batchEuclideanSquaredAVX2Flat(query, flatVectors, dim, results)
+    for i := range results[:len(flatVectors)/dim] {
+        results[i] = math.Sqrt(results[i])
+    }
+}
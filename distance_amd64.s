@@ -1,13 +1,16 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
 #include "textflag.h"
 
-// func euclideanAVX2(v1, v2 []float64) float64
-TEXT ·euclideanAVX2(SB), NOSPLIT, $0-48
-    MOVQ    v1+0(FP), SI     // v1 slice
-    MOVQ    v1_len+8(FP), BX // length
-    MOVQ    v2+24(FP), DI    // v2 slice
-    VXORPD  Y0, Y0, Y0       // sum = 0
+// func euclideanSquaredAVX2(v1, v2 []float64) float64
+TEXT ·euclideanSquaredAVX2(SB), NOSPLIT, $0-56
+    MOVQ    v1_base+0(FP), SI  // v1 slice
+    MOVQ    v1_len+8(FP), BX   // length
+    MOVQ    v2_base+24(FP), DI // v2 slice
+    VXORPD  Y0, Y0, Y0         // sum = 0
     MOVQ    BX, CX
-    SHRQ    $2, CX           // len/4 (process 4 doubles at a time)
+    SHRQ    $2, CX             // len/4 (process 4 doubles at a time)
     JZ      done_loop
 
 euclidean_loop:
@@ -27,22 +30,35 @@ done_loop:
     VADDPD  X1, X0, X0
     MOVHLPS X0, X1
     ADDSD   X1, X0
-    SQRTSD  X0, X0          // sqrt of sum
-    
-    MOVSD   X0, ret+40(FP)
+
+    ANDQ    $3, BX           // len%4 elements left
+    JZ      euclidean_done
+
+euclidean_remainder:
+    MOVSD   (SI), X1
+    SUBSD   (DI), X1         // diff = v1 - v2
+    MULSD   X1, X1           // square
+    ADDSD   X1, X0           // add to sum
+    ADDQ    $8, SI
+    ADDQ    $8, DI
+    DECQ    BX
+    JNZ     euclidean_remainder
+
+euclidean_done:
+    MOVSD   X0, ret+48(FP)
     VZEROUPPER
     RET
 
 // func cosineAVX2(v1, v2 []float64) float64
-TEXT ·cosineAVX2(SB), NOSPLIT, $0-48
-    MOVQ    v1+0(FP), SI     // v1 slice
-    MOVQ    v1_len+8(FP), BX // length
-    MOVQ    v2+24(FP), DI    // v2 slice
-    VXORPD  Y0, Y0, Y0       // dot = 0
-    VXORPD  Y1, Y1, Y1       // norm1 = 0
-    VXORPD  Y2, Y2, Y2       // norm2 = 0
+TEXT ·cosineAVX2(SB), NOSPLIT, $0-56
+    MOVQ    v1_base+0(FP), SI  // v1 slice
+    MOVQ    v1_len+8(FP), BX   // length
+    MOVQ    v2_base+24(FP), DI // v2 slice
+    VXORPD  Y0, Y0, Y0         // dot = 0
+    VXORPD  Y1, Y1, Y1         // norm1 = 0
+    VXORPD  Y2, Y2, Y2         // norm2 = 0
     MOVQ    BX, CX
-    SHRQ    $2, CX           // len/4
+    SHRQ    $2, CX             // len/4
     JZ      done_cosine
 
 cosine_loop:
@@ -75,7 +91,26 @@ done_cosine:
     VADDPD  X3, X2, X2
     MOVHLPS X2, X3
     ADDSD   X3, X2           // final norm2
-    
+
+    ANDQ    $3, BX           // len%4 elements left
+    JZ      cosine_finish
+
+cosine_remainder:
+    MOVSD   (SI), X3         // load v1
+    MOVSD   (DI), X4         // load v2
+    MOVSD   X3, X5
+    MULSD   X4, X5           // v1 * v2
+    ADDSD   X5, X0           // add to dot
+    MULSD   X3, X3           // v1 * v1
+    ADDSD   X3, X1           // add to norm1
+    MULSD   X4, X4           // v2 * v2
+    ADDSD   X4, X2           // add to norm2
+    ADDQ    $8, SI
+    ADDQ    $8, DI
+    DECQ    BX
+    JNZ     cosine_remainder
+
+cosine_finish:
     // Calculate 1 - dot/(sqrt(norm1*norm2))
     MULSD   X2, X1           // norm1 * norm2
     SQRTSD  X1, X1           // sqrt(norm1 * norm2)
@@ -83,17 +118,63 @@ done_cosine:
     MOVSD   $1.0, X1
     SUBSD   X0, X1           // 1 - dot/sqrt(norm1*norm2)
     
-    MOVSD   X1, ret+40(FP)
+    MOVSD   X1, ret+48(FP)
     VZEROUPPER
     RET
 
-// func BatchEuclideanAVX2(query Vector, vectors []Vector, results []float64)
-TEXT ·BatchEuclideanAVX2(SB), NOSPLIT, $0-72
-    MOVQ    query+0(FP), SI         // query data pointer
-    MOVQ    query_len+8(FP), R8     // query length
-    MOVQ    vectors+24(FP), DI      // vectors slice header
-    MOVQ    vectors_len+32(FP), CX  // number of vectors
-    MOVQ    results+48(FP), R9      // results data pointer
+// func dotAVX2(v1, v2 []float64) float64
+TEXT ·dotAVX2(SB), NOSPLIT, $0-56
+    MOVQ    v1_base+0(FP), SI  // v1 slice
+    MOVQ    v1_len+8(FP), BX   // length
+    MOVQ    v2_base+24(FP), DI // v2 slice
+    VXORPD  Y0, Y0, Y0         // dot = 0
+    MOVQ    BX, CX
+    SHRQ    $2, CX             // len/4
+    JZ      dot_hsum
+
+dot_loop:
+    VMOVUPD (SI), Y1           // load v1
+    VMOVUPD (DI), Y2           // load v2
+    VMULPD  Y1, Y2, Y3         // v1 * v2
+    VADDPD  Y3, Y0, Y0         // add to dot
+    ADDQ    $32, SI
+    ADDQ    $32, DI
+    DECQ    CX
+    JNZ     dot_loop
+
+dot_hsum:
+    // Horizontal sum
+    VEXTRACTF128 $1, Y0, X1
+    VADDPD  X1, X0, X0
+    MOVHLPS X0, X1
+    ADDSD   X1, X0
+
+    ANDQ    $3, BX             // len%4 elements left
+    JZ      dot_done
+
+dot_remainder:
+    MOVSD   (SI), X1
+    MULSD   (DI), X1
+    ADDSD   X1, X0
+    ADDQ    $8, SI
+    ADDQ    $8, DI
+    DECQ    BX
+    JNZ     dot_remainder
+
+dot_done:
+    MOVSD   X0, ret+48(FP)
+    VZEROUPPER
+    RET
+
+// func batchEuclideanAVX2(query Vector, vectors []Vector, results []float64)
+TEXT ·batchEuclideanAVX2(SB), NOSPLIT, $0-72
+    MOVQ    query_base+0(FP), SI     // query data pointer
+    MOVQ    query_len+8(FP), R8      // query length
+    MOVQ    vectors_base+24(FP), DI  // vectors slice headers
+    MOVQ    vectors_len+32(FP), CX   // number of vectors
+    MOVQ    results_base+48(FP), R9  // results data pointer
+    MOVQ    R8, R13
+    ANDQ    $-4, R13                 // components in blocks of 4
     
     XORQ    R10, R10               // vector index = 0
 
@@ -102,29 +183,42 @@ vector_loop:
     JGE     done
     
     // Load vector pointer
-    MOVQ    (DI)(R10*8), R11       // R11 = vectors[i]
+    MOVQ    (DI), R11              // R11 = vectors[i] data pointer
+    ADDQ    $24, DI                // advance to the next slice header
     VXORPD  Y0, Y0, Y0             // sum = 0
     XORQ    R12, R12               // dim = 0
     
     // Process vector in chunks of 4 doubles
 dim_loop:
-    ADDQ    $32, R12               // Increment by 4 doubles (32 bytes)
-    CMPQ    R12, R8                
-    JG      finish_vector          // If we've processed all dimensions
+    CMPQ    R12, R13
+    JGE     dim_tail               // If all blocks of 4 are processed
     
-    VMOVUPD -32(SI)(R12*1), Y1    // Load 4 doubles from query
-    VMOVUPD -32(R11)(R12*1), Y2   // Load 4 doubles from vector
+    VMOVUPD (SI)(R12*8), Y1       // Load 4 doubles from query
+    VMOVUPD (R11)(R12*8), Y2      // Load 4 doubles from vector
     VSUBPD  Y2, Y1, Y3            // Subtract
     VMULPD  Y3, Y3, Y3            // Square differences
     VADDPD  Y3, Y0, Y0            // Add to sum
+    ADDQ    $4, R12
     JMP     dim_loop
 
-finish_vector:
+dim_tail:
     // Sum the lanes of Y0
     VEXTRACTF128 $1, Y0, X1
     VADDPD  X1, X0, X0
     MOVHLPS X0, X1
     ADDSD   X1, X0
+
+tail_loop:
+    CMPQ    R12, R8               // Remaining len%4 dimensions
+    JGE     finish_vector
+    MOVSD   (SI)(R12*8), X1
+    SUBSD   (R11)(R12*8), X1
+    MULSD   X1, X1
+    ADDSD   X1, X0
+    INCQ    R12
+    JMP     tail_loop
+
+finish_vector:
     SQRTSD  X0, X0
     
     MOVSD   X0, (R9)(R10*8)       // Store result
@@ -136,15 +230,19 @@ done:
     VZEROUPPER
     RET
 
-// func BatchEuclideanAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64)
-TEXT ·BatchEuclideanAVX2Flat(SB), NOSPLIT, $0-56
-    MOVQ query+0(FP), SI           // query ptr
-    MOVQ flatVectors+24(FP), DI    // flatVectors ptr
-    MOVQ dim+48(FP), R8            // dimension
-    MOVQ results+56(FP), R9        // results ptr
+// func batchEuclideanSquaredAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64)
+TEXT ·batchEuclideanSquaredAVX2Flat(SB), NOSPLIT, $0-80
+    MOVQ query_base+0(FP), SI         // query ptr
+    MOVQ flatVectors_base+24(FP), DI  // flatVectors ptr
+    MOVQ dim+48(FP), R8               // dimension
+    MOVQ results_base+56(FP), R9      // results ptr
     
-    MOVQ flatVectors_len+32(FP), CX
-    SHRQ $10, CX                   // divide by 1024 to get vector count
+    MOVQ flatVectors_len+32(FP), AX
+    XORQ DX, DX
+    DIVQ R8                        // vector count = len / dim
+    MOVQ AX, CX
+    MOVQ R8, R13
+    ANDQ $-4, R13                  // components in blocks of 4
     
     XORQ R10, R10                  // vector index
 
@@ -156,12 +254,12 @@ vector_loop:
     XORQ   R11, R11               // dimension counter
     
     MOVQ R10, R12
-    SHLQ $10, R12                 // multiply by 1024 to get offset
+    IMULQ R8, R12                 // multiply by dim to get offset
     LEAQ (DI)(R12*8), R12         // current vector address
 
 dim_loop:
-    CMPQ R11, R8
-    JGE  finish_vector
+    CMPQ R11, R13
+    JGE  dim_tail
     
     VMOVUPD (SI)(R11*8), Y1       // load 4 query elements
     VMOVUPD (R12)(R11*8), Y2      // load 4 vector elements
@@ -172,14 +270,24 @@ dim_loop:
     ADDQ $4, R11
     JMP  dim_loop
 
-finish_vector:
-    // Horizontal sum and sqrt
+dim_tail:
+    // Horizontal sum
     VEXTRACTF128 $1, Y0, X1
     VADDPD  X1, X0, X0
     MOVHLPS X0, X1
     ADDSD   X1, X0
-    SQRTSD  X0, X0
-    
+
+tail_loop:
+    CMPQ R11, R8                  // remaining dim%4 elements
+    JGE  finish_vector
+    MOVSD (SI)(R11*8), X1
+    SUBSD (R12)(R11*8), X1
+    MULSD X1, X1
+    ADDSD X1, X0
+    INCQ R11
+    JMP  tail_loop
+
+finish_vector:
     MOVSD X0, (R9)(R10*8)
     
     INCQ R10
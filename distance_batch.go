@@ -1,43 +1,58 @@
-//go:build amd64
-// +build amd64
-
 // distance_batch.go
 package hnsw
 
 import "math"
 
-// BatchEuclideanAVX2 calculates distances between query vector and multiple vectors
-//
-//go:noescape
-func BatchEuclideanAVX2(query Vector, vectors []Vector, results []float64)
-
-// BatchEuclideanAVX2Flat calculates distances between query vector and multiple vectors
-//
-//go:noescape
-func BatchEuclideanAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64)
-
 // BatchEuclidean computes distances between query and multiple vectors
 func BatchEuclidean(query Vector, vectors []Vector) []float64 {
+    results := BatchEuclideanSquared(query, vectors)
+    for i := range results {
+        results[i] = math.Sqrt(results[i])
+    }
+    return results
+}
+
+// BatchEuclideanSquared computes squared distances between query and multiple
+// vectors, the batch form of EuclideanSquared
+func BatchEuclideanSquared(query Vector, vectors []Vector) []float64 {
     if len(vectors) == 0 {
         return []float64{}
     }
 
-    // Flatten vectors into contiguous memory
-    dim := len(query)
-    flatData := make([]float64, len(vectors)*dim)
+    return batchEuclideanSquared(query, vectors)
+}
+
+// BatchCosine computes cosine distances between query and multiple vectors,
+// computing the query norm only once
+func BatchCosine(query Vector, vectors []Vector) []float64 {
+    var queryNorm float64
+    for _, q := range query {
+        queryNorm += q * q
+    }
+
+    results := make([]float64, len(vectors))
     for i, vec := range vectors {
-        copy(flatData[i*dim:], vec)
+        var dot, norm float64
+        for j, q := range query {
+            dot += q * vec[j]
+            norm += vec[j] * vec[j]
+        }
+        results[i] = 1 - dot/math.Sqrt(queryNorm*norm)
     }
+    return results
+}
 
+// BatchDot computes the negated dot products of query and multiple vectors,
+// the batch form of InnerProduct
+func BatchDot(query Vector, vectors []Vector) []float64 {
     results := make([]float64, len(vectors))
-    if useAVX2 && dim >= 4 {
-        BatchEuclideanAVX2Flat(query, flatData, dim, results)
-        return results
+    for i, vec := range vectors {
+        results[i] = -dot(query, vec)
     }
-    return batchEuclideanFallback(query, vectors)
+    return results
 }
 
-func batchEuclideanFallback(query Vector, vectors []Vector) []float64 {
+func batchEuclideanSquaredFallback(query Vector, vectors []Vector) []float64 {
     results := make([]float64, len(vectors))
     for i, vec := range vectors {
         var sum float64
@@ -45,7 +60,21 @@ func batchEuclideanFallback(query Vector, vectors []Vector) []float64 {
             d := query[j] - vec[j]
             sum += d * d
         }
-        results[i] = math.Sqrt(sum)
+        results[i] = sum
     }
     return results
 }
+
+// Fallback implementation of BatchEuclideanAVX2
+func batchEuclideanFallback(query Vector, vectors []Vector, results []float64) {
+    for i, vec := range vectors {
+        results[i] = math.Sqrt(euclideanSquaredFallback(query, vec))
+    }
+}
+
+// Fallback implementation of BatchEuclideanAVX2Flat
+func batchEuclideanFlatFallback(query []float64, flatVectors []float64, dim int, results []float64) {
+    for i := range results[:len(flatVectors)/dim] {
+        results[i] = math.Sqrt(euclideanSquaredFallback(query, flatVectors[i*dim:(i+1)*dim]))
+    }
+}
@@ -0,0 +1,39 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+// distance_generic.go
+package hnsw
+
+// Without the assembly kernels every distance takes the Go fallbacks. Build
+// with the purego tag to get the same on amd64.
+
+func euclideanSquared(v1, v2 Vector) float64 {
+    return euclideanSquaredFallback(v1, v2)
+}
+
+func cosine(v1, v2 Vector) float64 {
+    return cosineFallback(v1, v2)
+}
+
+func dot(v1, v2 Vector) float64 {
+    return dotFallback(v1, v2)
+}
+
+func batchEuclideanSquared(query Vector, vectors []Vector) []float64 {
+    return batchEuclideanSquaredFallback(query, vectors)
+}
+
+// BatchEuclideanAVX2 calculates distances between query vector and multiple
+// vectors. This is the portable form of the amd64 kernel.
+func BatchEuclideanAVX2(query Vector, vectors []Vector, results []float64) {
+    batchEuclideanFallback(query, vectors, results)
+}
+
+// BatchEuclideanAVX2Flat calculates distances between query vector and
+// multiple vectors. This is the portable form of the amd64 kernel.
+func BatchEuclideanAVX2Flat(query []float64, flatVectors []float64, dim int, results []float64) {
+    if dim <= 0 {
+        return
+    }
+    batchEuclideanFlatFallback(query, flatVectors, dim, results)
+}
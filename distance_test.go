@@ -3,6 +3,7 @@ package hnsw
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -43,3 +44,202 @@ func TestCosine(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchEuclidean(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, dim := range []int{2, 4, 5, 8, 16, 33} {
+		query := make(Vector, dim)
+		for i := range query {
+			query[i] = rng.Float64()
+		}
+		vectors := make([]Vector, 7)
+		for i := range vectors {
+			vectors[i] = make(Vector, dim)
+			for j := range vectors[i] {
+				vectors[i][j] = rng.Float64()
+			}
+		}
+
+		got := BatchEuclidean(query, vectors)
+		for i, v := range vectors {
+			if want := euclideanFallback(query, v); math.Abs(got[i]-want) > 1e-10 {
+				t.Errorf("dim %d: BatchEuclidean()[%d] = %v; want %v", dim, i, got[i], want)
+			}
+		}
+	}
+}
+
+func TestBatchCosineDot(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	query := Vector{rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64()}
+	vectors := make([]Vector, 6)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64()}
+	}
+
+	cosine := BatchCosine(query, vectors)
+	dot := BatchDot(query, vectors)
+	for i, v := range vectors {
+		if want := cosineFallback(query, v); math.Abs(cosine[i]-want) > 1e-10 {
+			t.Errorf("BatchCosine()[%d] = %v; want %v", i, cosine[i], want)
+		}
+		var want float64
+		for j := range v {
+			want -= query[j] * v[j]
+		}
+		if math.Abs(dot[i]-want) > 1e-10 {
+			t.Errorf("BatchDot()[%d] = %v; want %v", i, dot[i], want)
+		}
+	}
+}
+
+func TestInnerProduct(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, dim := range []int{1, 3, 4, 8, 10, 13} {
+		v1, v2 := make(Vector, dim), make(Vector, dim)
+		var want float64
+		for i := range v1 {
+			v1[i], v2[i] = rng.NormFloat64(), rng.NormFloat64()
+			want -= v1[i] * v2[i]
+		}
+		if got := InnerProduct(v1, v2); math.Abs(got-want) > 1e-10 {
+			t.Errorf("dim %d: InnerProduct() = %v; want %v", dim, got, want)
+		}
+	}
+}
+
+func TestEuclideanSquared(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, dim := range []int{2, 3, 4, 16} {
+		query := make(Vector, dim)
+		vectors := make([]Vector, 5)
+		for i := range query {
+			query[i] = rng.Float64()
+		}
+		for i := range vectors {
+			vectors[i] = make(Vector, dim)
+			for j := range vectors[i] {
+				vectors[i][j] = rng.Float64()
+			}
+		}
+
+		batch := BatchEuclideanSquared(query, vectors)
+		for i, v := range vectors {
+			want := euclideanFallback(query, v)
+			want *= want
+			if got := EuclideanSquared(query, v); math.Abs(got-want) > 1e-10 {
+				t.Errorf("dim %d: EuclideanSquared() = %v; want %v", dim, got, want)
+			}
+			if math.Abs(batch[i]-want) > 1e-10 {
+				t.Errorf("dim %d: BatchEuclideanSquared()[%d] = %v; want %v", dim, i, batch[i], want)
+			}
+		}
+	}
+}
+
+// fuzzVectors returns a query and n vectors of the given dimension drawn
+// from seed, for differential tests against the Go fallbacks
+func fuzzVectors(seed int64, dim, n int) (Vector, []Vector) {
+	rng := rand.New(rand.NewSource(seed))
+	query := make(Vector, dim)
+	for i := range query {
+		query[i] = rng.NormFloat64()
+	}
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		vectors[i] = make(Vector, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.NormFloat64()
+		}
+	}
+	return query, vectors
+}
+
+// closeTo reports whether got matches want up to rounding from the order
+// the SIMD kernels accumulate in
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+}
+
+// addDimSeeds seeds f with every remainder of a 4-wide block on either side
+// of the scalar cutoff
+func addDimSeeds(f *testing.F) {
+	for dim := 1; dim <= 19; dim++ {
+		f.Add(int64(dim), uint8(dim))
+	}
+	f.Add(int64(99), uint8(127))
+	f.Add(int64(100), uint8(255))
+}
+
+func FuzzEuclidean(f *testing.F) {
+	addDimSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, d uint8) {
+		dim := int(d) + 1
+		query, vectors := fuzzVectors(seed, dim, 1)
+		want := euclideanFallback(query, vectors[0])
+		if got := Euclidean(query, vectors[0]); !closeTo(got, want) {
+			t.Errorf("dim %d: Euclidean() = %v; want %v", dim, got, want)
+		}
+		if got := EuclideanSquared(query, vectors[0]); !closeTo(got, want*want) {
+			t.Errorf("dim %d: EuclideanSquared() = %v; want %v", dim, got, want*want)
+		}
+	})
+}
+
+func FuzzCosine(f *testing.F) {
+	addDimSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, d uint8) {
+		dim := int(d) + 1
+		query, vectors := fuzzVectors(seed, dim, 1)
+		want := cosineFallback(query, vectors[0])
+		if got := Cosine(query, vectors[0]); !closeTo(got, want) {
+			t.Errorf("dim %d: Cosine() = %v; want %v", dim, got, want)
+		}
+	})
+}
+
+func FuzzInnerProduct(f *testing.F) {
+	addDimSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, d uint8) {
+		dim := int(d) + 1
+		query, vectors := fuzzVectors(seed, dim, 1)
+		want := -dotFallback(query, vectors[0])
+		if got := InnerProduct(query, vectors[0]); !closeTo(got, want) {
+			t.Errorf("dim %d: InnerProduct() = %v; want %v", dim, got, want)
+		}
+	})
+}
+
+func FuzzBatchEuclidean(f *testing.F) {
+	addDimSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, d uint8) {
+		dim := int(d) + 1
+		query, vectors := fuzzVectors(seed, dim, 5)
+		want := batchEuclideanSquaredFallback(query, vectors)
+
+		squared := BatchEuclideanSquared(query, vectors)
+		for i := range vectors {
+			if !closeTo(squared[i], want[i]) {
+				t.Errorf("dim %d: BatchEuclideanSquared()[%d] = %v; want %v", dim, i, squared[i], want[i])
+			}
+		}
+
+		results := make([]float64, len(vectors))
+		BatchEuclideanAVX2(query, vectors, results)
+		flat := make([]float64, 0, len(vectors)*dim)
+		for _, v := range vectors {
+			flat = append(flat, v...)
+		}
+		flatResults := make([]float64, len(vectors))
+		BatchEuclideanAVX2Flat(query, flat, dim, flatResults)
+
+		for i := range vectors {
+			if w := math.Sqrt(want[i]); !closeTo(results[i], w) {
+				t.Errorf("dim %d: BatchEuclideanAVX2()[%d] = %v; want %v", dim, i, results[i], w)
+			}
+			if w := math.Sqrt(want[i]); !closeTo(flatResults[i], w) {
+				t.Errorf("dim %d: BatchEuclideanAVX2Flat()[%d] = %v; want %v", dim, i, flatResults[i], w)
+			}
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// diverse.go
+package hnsw
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// SearchDiverse returns k results chosen by Maximal Marginal Relevance from
+// the fetchK nearest neighbors of vec. Each pick maximizes
+//
+//	lambda*(-distance to vec) + (1-lambda)*(distance to the closest pick)
+//
+// so lambda 1 ranks by relevance alone and lambda 0 by diversity alone.
+// Distances between candidates use the index metric. Results keep their
+// distance to vec, in pick order.
+func (h *HNSW) SearchDiverse(vec Vector, k int, lambda float64, fetchK int) ([]SearchResult, error) {
+	if !(lambda >= 0 && lambda <= 1) {
+		return nil, fmt.Errorf("%w: lambda %v must be between 0 and 1", ErrInvalidConfig, lambda)
+	}
+	if err := h.validateVector(vec); err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return []SearchResult{}, nil
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	candidates, _ := h.search(context.Background(), vec, max(k, fetchK), DefaultSearchConfig(), nil)
+	if len(candidates) <= k {
+		return candidates, nil
+	}
+
+	// Distance from every candidate to the closest pick so far
+	nearestPick := make([]float64, len(candidates))
+	for i := range nearestPick {
+		nearestPick[i] = math.Inf(1)
+	}
+	picked := make([]bool, len(candidates))
+
+	results := make([]SearchResult, 0, k)
+	for len(results) < k {
+		best, bestScore := -1, math.Inf(-1)
+		for i, c := range candidates {
+			if picked[i] {
+				continue
+			}
+			// Nothing is picked yet on the first round, so rank by relevance
+			score := -c.Distance
+			if len(results) > 0 {
+				score = lambda*score + (1-lambda)*nearestPick[i]
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked[best] = true
+		results = append(results, candidates[best])
+		pick := h.Nodes[candidates[best].ID]
+		for i, c := range candidates {
+			if !picked[i] {
+				dist := h.DistanceFunc(h.Nodes[c.ID].Vector, pick.Vector)
+				nearestPick[i] = math.Min(nearestPick[i], dist)
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,84 @@
+// diverse_test.go
+package hnsw
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// minPairDistance returns the smallest distance between any two results
+func minPairDistance(h *HNSW, results []SearchResult) float64 {
+	closest := -1.0
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			d := Euclidean(h.Nodes[results[i].ID].Vector, h.Nodes[results[j].ID].Vector)
+			if closest < 0 || d < closest {
+				closest = d
+			}
+		}
+	}
+	return closest
+}
+
+func TestSearchDiverse(t *testing.T) {
+	h := New(2, 8, 16, 64, Euclidean)
+	h.SetSeed(39)
+	rng := rand.New(rand.NewSource(39))
+
+	// A tight cluster of near-duplicates next to the query, plus background
+	for i := 0; i < 20; i++ {
+		h.Insert(i, Vector{0.5 + rng.Float64()*0.001, 0.5 + rng.Float64()*0.001})
+	}
+	for i := 20; i < 500; i++ {
+		h.Insert(i, Vector{rng.Float64(), rng.Float64()})
+	}
+	query := Vector{0.5, 0.5}
+
+	plain, err := h.SearchWithDistances(query, 5, DefaultSearchConfig())
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+
+	diverse, err := h.SearchDiverse(query, 5, 0.5, 50)
+	if err != nil {
+		t.Fatalf("SearchDiverse() error = %v", err)
+	}
+	if len(diverse) != 5 {
+		t.Fatalf("got %d results, want 5", len(diverse))
+	}
+	if diverse[0].ID != plain[0].ID {
+		t.Errorf("first pick = %d; want the nearest neighbor %d", diverse[0].ID, plain[0].ID)
+	}
+	if got, base := minPairDistance(h, diverse), minPairDistance(h, plain); got <= base*10 {
+		t.Errorf("diverse results are %v apart, plain results %v", got, base)
+	}
+	for i, r := range diverse {
+		if want := Euclidean(h.Nodes[r.ID].Vector, query); r.Distance != want {
+			t.Errorf("result %d distance = %v; want %v", i, r.Distance, want)
+		}
+	}
+
+	// Relevance alone reproduces the plain ranking
+	relevant, err := h.SearchDiverse(query, 5, 1, 50)
+	if err != nil {
+		t.Fatalf("SearchDiverse() error = %v", err)
+	}
+	for i := range plain {
+		if relevant[i].ID != plain[i].ID {
+			t.Errorf("lambda=1: result %d = %d; want %d", i, relevant[i].ID, plain[i].ID)
+		}
+	}
+
+	for _, k := range []int{0, -1} {
+		if results, err := h.SearchDiverse(query, k, 0.5, 50); err != nil || len(results) != 0 {
+			t.Errorf("SearchDiverse() with k=%d = %v, %v; want no results", k, results, err)
+		}
+	}
+
+	for _, lambda := range []float64{-0.1, 1.5} {
+		if _, err := h.SearchDiverse(query, 5, lambda, 50); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("SearchDiverse() with lambda %v error = %v; want %v", lambda, err, ErrInvalidConfig)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// errors.go
+package hnsw
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrDimensionMismatch is returned for vectors whose length differs from the index dimension
+	ErrDimensionMismatch = errors.New("hnsw: vector dimension mismatch")
+	// ErrInvalidVector is returned for vectors the distance function can't handle,
+	// such as NaN or Inf components or the zero vector under Cosine
+	ErrInvalidVector = errors.New("hnsw: invalid vector")
+	// ErrDuplicateID is returned when inserting an ID that is already indexed
+	ErrDuplicateID = errors.New("hnsw: duplicate id")
+	// ErrNotFound is returned when an ID is not indexed
+	ErrNotFound = errors.New("hnsw: id not found")
+	// ErrInvalidConfig is returned by NewWithOptions for invalid parameter combinations
+	ErrInvalidConfig = errors.New("hnsw: invalid config")
+)
+
+// validateVector checks that vec can be stored in or used to query the index
+func (h *HNSW) validateVector(vec Vector) error {
+	if len(vec) != h.Dim {
+		return fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vec), h.Dim)
+	}
+
+	for i, v := range vec {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%w: component %d is %v", ErrInvalidVector, i, v)
+		}
+	}
+
+	// A metric that can't measure a vector against itself, like Cosine on the
+	// zero vector, would poison every distance computed against it
+	if math.IsNaN(h.DistanceFunc(vec, vec)) {
+		return fmt.Errorf("%w: distance is undefined for this vector", ErrInvalidVector)
+	}
+
+	return nil
+}
@@ -96,7 +96,9 @@ func main() {
 			wg.Add(1)
 			go func(id int, vec hnsw.Vector) {
 				defer wg.Done()
-				index.Insert(id, vec)
+				if err := index.Insert(id, vec); err != nil {
+					log.Printf("Failed to insert vector %d: %v", id, err)
+				}
 			}(i, vectors[i])
 		}
 		wg.Wait()
@@ -114,8 +116,11 @@ func main() {
 		WorkerCount: *workerCount,
 	}
 	startSearch := time.Now()
-	results := index.SearchWithConfig(queryVector, *searchK, config)
+	results, err := index.SearchWithConfig(queryVector, *searchK, config)
 	searchTime := time.Since(startSearch)
+	if err != nil {
+		log.Fatalf("Search failed: %v", err)
+	}
 
 	result := BenchmarkResult{
 		Dimension:      *dimension,
@@ -0,0 +1,101 @@
+// fusion.go
+package hnsw
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Fusion controls how SearchMulti merges the hits of several queries
+type Fusion int
+
+const (
+	// FusionMin ranks each vector by its distance to the closest query
+	FusionMin Fusion = iota
+	// FusionMean ranks each vector by its mean distance to all queries
+	FusionMean
+	// FusionRRF ranks each vector by reciprocal rank fusion of the per-query
+	// result lists, ignoring the distances themselves
+	FusionRRF
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion
+const rrfK = 60
+
+// SearchMulti searches for each query vector and fuses the hits into the k
+// best results. Every vector found by any query is scored against all queries
+// once, so a vector matching several queries appears once. Distances are the
+// fused distances; under FusionRRF, Distance is the negated fusion score so
+// that results stay ordered by ascending Distance. Only vectors found by some
+// query are considered, so FusionMean works best for nearby queries.
+func (h *HNSW) SearchMulti(queries []Vector, k int, fusion Fusion) ([]SearchResult, error) {
+	if fusion < FusionMin || fusion > FusionRRF {
+		return nil, fmt.Errorf("%w: unknown fusion %d", ErrInvalidConfig, fusion)
+	}
+	for i, query := range queries {
+		if err := h.validateVector(query); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if len(queries) == 0 || k <= 0 {
+		return []SearchResult{}, nil
+	}
+
+	// The layer search ranks a full ef candidate list, so keeping all of it
+	// widens the pool for fusion at no extra cost
+	config := DefaultSearchConfig()
+	fetch := h.searchEf(k, config)
+
+	scores := make(map[int]float64)
+	nodes := make(map[int]*Node)
+	for _, query := range queries {
+		hits, _ := h.search(context.Background(), query, fetch, config, nil)
+		for rank, hit := range hits {
+			nodes[hit.ID] = h.Nodes[hit.ID]
+			if fusion == FusionRRF {
+				scores[hit.ID] -= 1 / float64(rrfK+rank+1)
+			}
+		}
+	}
+
+	if fusion != FusionRRF {
+		for id, node := range nodes {
+			scores[id] = h.fuseDistances(node, queries, fusion)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, SearchResult{ID: id, Distance: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results[:min(k, len(results))], nil
+}
+
+// fuseDistances combines the distances from node to every query
+func (h *HNSW) fuseDistances(node *Node, queries []Vector, fusion Fusion) float64 {
+	fused := math.Inf(1)
+	if fusion == FusionMean {
+		fused = 0
+	}
+	for _, query := range queries {
+		dist := h.DistanceFunc(node.Vector, query)
+		if fusion == FusionMean {
+			fused += dist / float64(len(queries))
+		} else {
+			fused = math.Min(fused, dist)
+		}
+	}
+	return fused
+}
@@ -0,0 +1,110 @@
+// fusion_test.go
+package hnsw
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+)
+
+// exactFused ranks every vector by its fused distance to queries
+func exactFused(vectors []Vector, queries []Vector, k int, fusion Fusion) []int {
+	fused := make([]float64, len(vectors))
+	ids := make([]int, len(vectors))
+	for i, v := range vectors {
+		ids[i] = i
+		fused[i] = math.Inf(1)
+		if fusion == FusionMean {
+			fused[i] = 0
+		}
+		for _, q := range queries {
+			if fusion == FusionMean {
+				fused[i] += Euclidean(v, q) / float64(len(queries))
+			} else {
+				fused[i] = math.Min(fused[i], Euclidean(v, q))
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return fused[ids[i]] < fused[ids[j]] })
+	return ids[:k]
+}
+
+func TestSearchMulti(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 36)
+
+	tests := []struct {
+		name    string
+		queries []Vector
+		fusion  Fusion
+	}{
+		{"Min far apart", []Vector{{0.1, 0.1}, {0.9, 0.9}}, FusionMin},
+		{"Min overlapping", []Vector{{0.5, 0.5}, {0.51, 0.5}, {0.5, 0.52}}, FusionMin},
+		{"Mean overlapping", []Vector{{0.5, 0.5}, {0.52, 0.5}}, FusionMean},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := h.SearchMulti(tt.queries, 10, tt.fusion)
+			if err != nil {
+				t.Fatalf("SearchMulti() error = %v", err)
+			}
+			if len(results) != 10 {
+				t.Fatalf("got %d results, want 10", len(results))
+			}
+
+			seen := make(map[int]bool)
+			ids := make([]int, len(results))
+			for i, r := range results {
+				if seen[r.ID] {
+					t.Errorf("ID %d returned twice", r.ID)
+				}
+				seen[r.ID] = true
+				ids[i] = r.ID
+				if i > 0 && r.Distance < results[i-1].Distance {
+					t.Errorf("results not sorted at %d", i)
+				}
+			}
+			if got := recall(ids, exactFused(vectors, tt.queries, 10, tt.fusion)); got < 0.9 {
+				t.Errorf("fused recall@10 = %.2f; want >= 0.9", got)
+			}
+		})
+	}
+}
+
+func TestSearchMultiRRF(t *testing.T) {
+	h, _ := buildRandomIndex(t, 1000, 37)
+	query := Vector{0.3, 0.7}
+
+	// Fusing a query with itself keeps its ranking
+	want, err := h.SearchWithDistances(query, 10, DefaultSearchConfig())
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+	results, err := h.SearchMulti([]Vector{query, query}, 10, FusionRRF)
+	if err != nil {
+		t.Fatalf("SearchMulti() error = %v", err)
+	}
+	for i := range want {
+		if results[i].ID != want[i].ID {
+			t.Errorf("result %d = %d; want %d", i, results[i].ID, want[i].ID)
+		}
+		if wantScore := -2.0 / float64(rrfK+i+1); math.Abs(results[i].Distance-wantScore) > 1e-12 {
+			t.Errorf("result %d distance = %v; want %v", i, results[i].Distance, wantScore)
+		}
+	}
+}
+
+func TestSearchMultiInvalid(t *testing.T) {
+	h, _ := buildRandomIndex(t, 10, 38)
+
+	if _, err := h.SearchMulti([]Vector{{0, 0}}, 5, Fusion(9)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("SearchMulti() with unknown fusion error = %v; want %v", err, ErrInvalidConfig)
+	}
+	if _, err := h.SearchMulti([]Vector{{0, 0}, {0, 0, 0}}, 5, FusionMin); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("SearchMulti() with bad query error = %v; want %v", err, ErrDimensionMismatch)
+	}
+	if results, err := h.SearchMulti(nil, 5, FusionMin); err != nil || len(results) != 0 {
+		t.Errorf("SearchMulti(nil) = %v, %v; want no results", results, err)
+	}
+}
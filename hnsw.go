@@ -3,12 +3,19 @@ package hnsw
 
 import (
     "container/heap"
+    "context"
     "encoding/gob"
+    "fmt"
+    "iter"
+    "maps"
+    "math"
     "math/rand"
     "os"
     "runtime"
+    "slices"
     "sort"
     "sync"
+    "sync/atomic"
 )
 
 func init() {
@@ -25,9 +32,15 @@ type SerializableHNSW struct {
     MaxLevel       int
     M              int
     Mmax           int
+    Mmax0          int
     EfConstruction int
+    EfSearch       int
+    ExactThreshold int
+    LevelMult      float64
     Dim            int
     DeletedNodes   map[int]bool
+    DeleteMode     DeleteMode
+    VacuumRatio    float64
 }
 
 // HNSW represents the hierarchical navigable small world graph
@@ -36,103 +49,324 @@ type HNSW struct {
     EntryPoint     *Node
     MaxLevel       int
     M              int
-    Mmax           int
+    Mmax           int // Max connections on upper layers
+    Mmax0          int // Max connections on layer 0
     EfConstruction int
     Dim            int
     DistanceFunc   DistanceFunc
-    mutex          sync.RWMutex
-    deletedNodes   map[int]bool
+    // BatchDistanceFunc computes DistanceFunc for many vectors at once. When
+    // nil, the batch kernel of a built-in metric is used, or DistanceFunc in
+    // a loop for other metrics.
+    BatchDistanceFunc BatchDistanceFunc
+    Selector          NeighborSelector
+    mutex             sync.RWMutex
+    deletedNodes      map[int]bool
+    efSearch          int
+    exactThreshold    int
+    levelMult         float64
+    rng               *rand.Rand
+    deleteMode        DeleteMode
+    vacuumRatio       float64
+    vacuuming         atomic.Bool
 }
 
-// New creates a new HNSW index
+// New creates a new HNSW index. Nodes keep up to mmax connections on layer 0
+// and up to m on upper layers; use NewWithOptions to set both limits.
 func New(dim, m, mmax, efConstruction int, distanceFunc DistanceFunc) *HNSW {
     return &HNSW{
         Nodes:          make(map[int]*Node),
         MaxLevel:       0,
         M:              m,
-        Mmax:           mmax,
+        Mmax:           m,
+        Mmax0:          mmax,
         EfConstruction: efConstruction,
         Dim:            dim,
         DistanceFunc:   distanceFunc,
+        Selector:       HeuristicSelector{},
         deletedNodes:   make(map[int]bool),
+        levelMult:      defaultLevelMult(m),
+        rng:            rand.New(rand.NewSource(rand.Int63())),
     }
 }
 
-// Insert adds a new vector to the index
-func (h *HNSW) Insert(id int, vec Vector) {
+// defaultLevelMult returns the level multiplier mL = 1/ln(M) from the paper
+func defaultLevelMult(m int) float64 {
+    if m < 2 {
+        return 1 / math.Ln2
+    }
+    return 1 / math.Log(float64(m))
+}
+
+// SetSeed reseeds the random source used to assign node levels. Building the
+// same data in the same insertion order with the same seed yields an identical graph.
+func (h *HNSW) SetSeed(seed int64) {
+    h.SetRandSource(rand.NewSource(seed))
+}
+
+// SetRandSource sets the random source used to assign node levels
+func (h *HNSW) SetRandSource(src rand.Source) {
     h.mutex.Lock()
     defer h.mutex.Unlock()
+    h.rng = rand.New(src)
+}
 
-    newNode := &Node{
-        ID:     id,
-        Vector: vec,
-        Levels: make([]*Level, 1),
+// SetLevelMultiplier sets the level multiplier mL. Levels are drawn as
+// floor(-ln(U) * mL); a value <= 0 restores the default of 1/ln(M).
+func (h *HNSW) SetLevelMultiplier(mL float64) {
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+    if mL <= 0 {
+        mL = defaultLevelMult(h.M)
     }
-    newNode.Levels[0] = &Level{Connections: make([]*Node, 0)}
+    h.levelMult = mL
+}
 
-    if len(h.Nodes) == 0 {
-        h.EntryPoint = newNode
-        h.Nodes[id] = newNode
-        return
+// Insert adds a new vector to the index. It returns ErrDuplicateID if the ID
+// is already indexed; use Update or Upsert to replace a vector.
+func (h *HNSW) Insert(id int, vec Vector) error {
+    return h.InsertContext(context.Background(), id, vec)
+}
+
+// InsertContext is like Insert but stops searching for neighbors once ctx is
+// done. A cancelled insert returns ctx.Err() and leaves the index unchanged.
+func (h *HNSW) InsertContext(ctx context.Context, id int, vec Vector) error {
+    if err := h.validateVector(vec); err != nil {
+        return err
+    }
+    if err := ctx.Err(); err != nil {
+        return err
     }
 
-    // Find entry point
-    currentNode := h.EntryPoint
-    currentDist := h.DistanceFunc(currentNode.Vector, vec)
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
 
-    // Search through existing layers
-    maxLevel := min(h.MaxLevel, len(currentNode.Levels)-1)
-    for level := maxLevel; level >= 0; level-- {
-        changed := true
-        for changed {
-            changed = false
-            currentNode.RLock()
-            if level < len(currentNode.Levels) && currentNode.Levels[level] != nil {
-                for _, neighbor := range currentNode.Levels[level].Connections {
-                    if neighbor == nil {
-                        continue
-                    }
-                    neighborDist := h.DistanceFunc(neighbor.Vector, vec)
-                    if neighborDist < currentDist {
-                        currentNode.RUnlock()
-                        currentNode = neighbor
-                        currentDist = neighborDist
-                        changed = true
-                        break
-                    }
-                }
-            }
-            if !changed {
-                currentNode.RUnlock()
-            }
+    if _, exists := h.Nodes[id]; exists && !h.deletedNodes[id] {
+        return fmt.Errorf("%w: %d", ErrDuplicateID, id)
+    }
+    _, err := h.insert(ctx, id, vec)
+    return err
+}
+
+// Update replaces the vector of an existing ID and relinks the node at its new
+// position. It returns ErrNotFound and leaves the index unchanged if the ID is not indexed.
+func (h *HNSW) Update(id int, vec Vector) error {
+    if err := h.validateVector(vec); err != nil {
+        return err
+    }
+
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+
+    if _, exists := h.Nodes[id]; !exists || h.deletedNodes[id] {
+        return fmt.Errorf("%w: %d", ErrNotFound, id)
+    }
+    _, err := h.insert(context.Background(), id, vec)
+    return err
+}
+
+// Upsert inserts the vector, or replaces it if the ID is already indexed.
+// It reports whether an existing vector was replaced.
+func (h *HNSW) Upsert(id int, vec Vector) (bool, error) {
+    if err := h.validateVector(vec); err != nil {
+        return false, err
+    }
+
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+    return h.insert(context.Background(), id, vec)
+}
+
+// insert links a new node into the graph, or moves the node already stored
+// under the same ID to vec. It reports whether a live node was replaced.
+// Neighbors are found on every level before the graph is modified, so if ctx
+// is done first insert returns its error and leaves the index unchanged.
+// The caller must hold the write lock.
+func (h *HNSW) insert(ctx context.Context, id int, vec Vector) (bool, error) {
+    if node, exists := h.Nodes[id]; exists {
+        replaced := !h.deletedNodes[id]
+        if err := h.update(ctx, node, vec); err != nil {
+            return false, err
         }
+        delete(h.deletedNodes, id)
+        return replaced, nil
     }
 
     // Generate random level
     newLevel := h.randomLevel()
+
+    newNode := &Node{
+        ID:       id,
+        Vector:   vec,
+        Levels:   make([]*Level, newLevel+1),
+        MaxLevel: newLevel,
+    }
+    for i := range newNode.Levels {
+        newNode.Levels[i] = &Level{Connections: make([]*Node, 0)}
+    }
+
+    candidates, err := h.searchNeighbors(vec, newLevel, &searchOpts{ctx: ctx})
+    if err != nil {
+        return false, err
+    }
+
+    if len(h.Nodes) == 0 || h.EntryPoint == nil {
+        h.EntryPoint = newNode
+        h.MaxLevel = newLevel
+        h.Nodes[id] = newNode
+        return false, nil
+    }
+
+    for level, levelCandidates := range candidates {
+        selected := h.selectNeighbors(newNode, levelCandidates, h.M, level)
+        newNode.setConnections(level, selected)
+        for _, neighbor := range selected {
+            h.addConnection(neighbor, newNode, level)
+        }
+    }
+
+    // The new node becomes the entry point when it reaches a new top level
     if newLevel > h.MaxLevel {
         h.MaxLevel = newLevel
+        h.EntryPoint = newNode
     }
 
-    // Initialize all levels
-    for i := len(newNode.Levels); i <= newLevel; i++ {
-        newNode.Levels = append(newNode.Levels, &Level{
-            Connections: make([]*Node, 0),
-        })
+    h.Nodes[id] = newNode
+    return false, nil
+}
+
+// update moves node to vec in place, like hnswlib's updatePoint. The node
+// keeps its levels; on each of them its old neighbors reselect their links
+// among its two-hop neighborhood, and the node links to the closest nodes at
+// its new position. Only that neighborhood is touched, never the whole graph.
+// If ctx is done before the new neighbors are found, update returns its error
+// and leaves the index unchanged. The caller must hold the write lock.
+func (h *HNSW) update(ctx context.Context, node *Node, vec Vector) error {
+    // The node still guides the search but can't be its own neighbor
+    opts := &searchOpts{ctx: ctx, accept: func(n *Node) bool { return n != node }}
+    candidates, err := h.searchNeighbors(vec, len(node.Levels)-1, opts)
+    if err != nil {
+        return err
     }
 
-    // Build connections for each level
-    for level := 0; level <= newLevel; level++ {
-        neighbors := h.searchLayer(currentNode, vec, h.M, level)
-        if level < len(newNode.Levels) {
-            newNode.Levels[level].Connections = neighbors
-            for _, neighbor := range neighbors {
-                h.addConnection(neighbor, newNode, level)
+    node.Lock()
+    node.Vector = vec
+    node.Unlock()
+
+    for level, levelCandidates := range candidates {
+        if node.Levels[level] == nil {
+            continue
+        }
+        h.relinkNeighborhood(node, level)
+
+        selected := h.selectNeighbors(node, levelCandidates, h.M, level)
+        node.Lock()
+        node.setConnections(level, selected)
+        node.Unlock()
+        for _, neighbor := range selected {
+            h.addConnection(neighbor, node, level)
+        }
+    }
+    return nil
+}
+
+// relinkNeighborhood reselects the links of every neighbor of node on level
+// after node has moved. Each neighbor chooses among node's two-hop
+// neighborhood, narrowed to the efConstruction candidates closest to it.
+func (h *HNSW) relinkNeighborhood(node *Node, level int) {
+    node.RLock()
+    neighbors := append([]*Node(nil), node.Levels[level].Connections...)
+    node.RUnlock()
+
+    twoHop := append([]*Node{node}, neighbors...)
+    for _, neighbor := range neighbors {
+        neighbor.RLock()
+        if level < len(neighbor.Levels) && neighbor.Levels[level] != nil {
+            twoHop = append(twoHop, neighbor.Levels[level].Connections...)
+        }
+        neighbor.RUnlock()
+    }
+
+    distance := h.metric().distance
+    ef := max(h.EfConstruction, h.M)
+    for _, neighbor := range neighbors {
+        ranked := sortByDistance(neighbor, twoHop, distance)
+        candidates := make([]*Node, 0, min(len(ranked), ef))
+        for i := 0; i < len(ranked) && i < ef; i++ {
+            candidates = append(candidates, ranked[i].node)
+        }
+
+        selected := h.selectNeighbors(neighbor, candidates, h.maxConnections(level), level)
+        neighbor.Lock()
+        if level < len(neighbor.Levels) && neighbor.Levels[level] != nil {
+            neighbor.setConnections(level, selected)
+        }
+        neighbor.Unlock()
+    }
+}
+
+// searchNeighbors finds the efConstruction nearest candidates of vec on every
+// level from top down to 0, seeding each level with the candidates found on
+// the one above. It returns the opts context error if that is done first.
+func (h *HNSW) searchNeighbors(vec Vector, top int, opts *searchOpts) ([][]*Node, error) {
+    candidates := make([][]*Node, top+1)
+    if h.EntryPoint == nil {
+        return candidates, opts.err()
+    }
+
+    // Greedy descent through the levels above top
+    entry := h.greedySearch(h.EntryPoint, vec, h.MaxLevel, top+1, opts)
+
+    ef := max(h.EfConstruction, h.M)
+    entryPoints := []*Node{entry}
+    for level := min(top, h.MaxLevel); level >= 0; level-- {
+        candidates[level] = nodesOf(h.searchLayer(entryPoints, vec, ef, level, opts))
+        if err := opts.err(); err != nil {
+            return nil, err
+        }
+        if len(candidates[level]) > 0 {
+            entryPoints = candidates[level]
+        }
+    }
+    return candidates, nil
+}
+
+// greedySearch descends from entry through levels fromLevel down to toLevel,
+// moving to the closest neighbor on each level until none is closer. It stops
+// at the closest node found so far once the opts context is done.
+func (h *HNSW) greedySearch(entry *Node, vec Vector, fromLevel, toLevel int, opts *searchOpts) *Node {
+    distance := h.metric().distance
+    currentNode := entry
+    currentDist := distance(currentNode.Vector, vec)
+    opts.countVisit(1)
+
+    for level := fromLevel; level >= toLevel && opts.err() == nil; level-- {
+        changed := true
+        for changed && opts.err() == nil {
+            changed = false
+            currentNode.RLock()
+            var connections []*Node
+            if level < len(currentNode.Levels) && currentNode.Levels[level] != nil {
+                connections = currentNode.Levels[level].Connections
+            }
+            opts.countHop(level)
+            next := currentNode
+            for _, neighbor := range connections {
+                if neighbor == nil {
+                    continue
+                }
+                opts.countVisit(1)
+                if neighborDist := distance(neighbor.Vector, vec); neighborDist < currentDist {
+                    next = neighbor
+                    currentDist = neighborDist
+                    changed = true
+                }
             }
+            currentNode.RUnlock()
+            currentNode = next
         }
     }
 
-    h.Nodes[id] = newNode
+    return currentNode
 }
 
 // Search finds k nearest neighbors for the given vector
@@ -201,22 +435,141 @@ func (h *HNSW) Insert(id int, vec Vector) {
 //     return result
 // }
 
-// Delete removes a vector from the index
-func (h *HNSW) Delete(id int) {
+// DeleteMode controls how Delete removes nodes from the graph
+type DeleteMode int
+
+const (
+    // DeleteRepair unlinks the node immediately and repairs its neighborhood
+    DeleteRepair DeleteMode = iota
+    // DeleteTombstone hides the node from results but keeps it navigable
+    // until Vacuum removes it
+    DeleteTombstone
+)
+
+// SetDeleteMode sets how subsequent calls to Delete remove nodes
+func (h *HNSW) SetDeleteMode(mode DeleteMode) {
     h.mutex.Lock()
     defer h.mutex.Unlock()
+    h.deleteMode = mode
+}
 
-    if _, exists := h.Nodes[id]; !exists {
-        return
+// Delete removes a vector from the index. In DeleteRepair mode the node is
+// unlinked from every neighbor list and the neighbors it leaves behind are
+// reconnected; in DeleteTombstone mode that work is deferred to Vacuum.
+// It returns ErrNotFound if the ID is not indexed.
+func (h *HNSW) Delete(id int) error {
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+
+    node, exists := h.Nodes[id]
+    if !exists || h.deletedNodes[id] {
+        return fmt.Errorf("%w: %d", ErrNotFound, id)
     }
 
-    h.deletedNodes[id] = true
+    if h.deleteMode == DeleteTombstone {
+        h.deletedNodes[id] = true
+        h.maybeAutoVacuum()
+        return nil
+    }
+
+    // Only the nodes linking to the deleted one need repairs
     delete(h.Nodes, id)
+    h.unlinkNodes(map[*Node]bool{node: true}, slices.Values(node.linkedFrom()))
+    return nil
+}
+
+// removeNodes is unlinkNodes sweeping every node of the graph. Batch removals
+// pay for the sweep once, and it also drops links the inbound lists missed.
+func (h *HNSW) removeNodes(removed map[*Node]bool) {
+    h.unlinkNodes(removed, maps.Values(h.Nodes))
+}
+
+// unlinkNodes unlinks the given nodes from the linking nodes and repairs the
+// neighborhoods that pointed at them, using the removed nodes' own neighbors
+// as replacement candidates. The caller must hold the write lock and have
+// already removed the nodes from h.Nodes.
+func (h *HNSW) unlinkNodes(removed map[*Node]bool, linking iter.Seq[*Node]) {
+    type repair struct {
+        node  *Node
+        level int
+        lost  []*Node
+    }
+
+    // Unlink first so that repairs never select a removed node again
+    var repairs []repair
+    for other := range linking {
+        if removed[other] {
+            continue
+        }
+        other.Lock()
+        for level, l := range other.Levels {
+            if l == nil {
+                continue
+            }
+            var lost []*Node
+            for _, conn := range l.Connections {
+                if removed[conn] {
+                    lost = append(lost, conn)
+                }
+            }
+            if len(lost) == 0 {
+                continue
+            }
+            kept := make([]*Node, 0, len(l.Connections)-len(lost))
+            for _, conn := range l.Connections {
+                if !removed[conn] {
+                    kept = append(kept, conn)
+                }
+            }
+            other.setConnections(level, kept)
+            repairs = append(repairs, repair{other, level, lost})
+        }
+        other.Unlock()
+    }
+
+    // Reconnect each orphaned neighbor through the removed nodes' neighborhoods
+    for _, r := range repairs {
+        r.node.Lock()
+        candidates := append([]*Node(nil), r.node.Levels[r.level].Connections...)
+        for _, lost := range r.lost {
+            if r.level >= len(lost.Levels) || lost.Levels[r.level] == nil {
+                continue
+            }
+            for _, conn := range lost.Levels[r.level].Connections {
+                if !removed[conn] {
+                    candidates = append(candidates, conn)
+                }
+            }
+        }
+        r.node.setConnections(r.level, h.selectNeighbors(r.node, candidates, h.maxConnections(r.level), r.level))
+        r.node.Unlock()
+    }
+
+    for node := range removed {
+        node.Lock()
+        for i, level := range node.Levels {
+            if level != nil {
+                node.setConnections(i, nil)
+            }
+        }
+        node.Unlock()
+    }
 
-    if h.EntryPoint.ID == id {
-        for _, node := range h.Nodes {
+    if removed[h.EntryPoint] {
+        h.resetEntryPoint()
+    }
+}
+
+// resetEntryPoint makes the node on the highest level the entry point,
+// preferring the lowest ID so that rebuilt graphs stay reproducible
+func (h *HNSW) resetEntryPoint() {
+    h.EntryPoint = nil
+    h.MaxLevel = 0
+    for _, node := range h.Nodes {
+        level := len(node.Levels) - 1
+        if h.EntryPoint == nil || level > h.MaxLevel || (level == h.MaxLevel && node.ID < h.EntryPoint.ID) {
             h.EntryPoint = node
-            break
+            h.MaxLevel = level
         }
     }
 }
@@ -231,9 +584,15 @@ func (h *HNSW) Save(filename string) error {
         MaxLevel:       h.MaxLevel,
         M:              h.M,
         Mmax:           h.Mmax,
+        Mmax0:          h.Mmax0,
         EfConstruction: h.EfConstruction,
+        EfSearch:       h.efSearch,
+        ExactThreshold: h.exactThreshold,
+        LevelMult:      h.levelMult,
         Dim:            h.Dim,
         DeletedNodes:   h.deletedNodes,
+        DeleteMode:     h.deleteMode,
+        VacuumRatio:    h.vacuumRatio,
     }
 
     if h.EntryPoint != nil {
@@ -292,12 +651,29 @@ func Load(filename string, distanceFunc DistanceFunc) (*HNSW, error) {
         MaxLevel:       serialized.MaxLevel,
         M:              serialized.M,
         Mmax:           serialized.Mmax,
+        Mmax0:          serialized.Mmax0,
         EfConstruction: serialized.EfConstruction,
         Dim:            serialized.Dim,
         DistanceFunc:   distanceFunc,
-        deletedNodes:   serialized.DeletedNodes,
+        Selector:       HeuristicSelector{},
+        deletedNodes:   make(map[int]bool),
+        efSearch:       serialized.EfSearch,
+        exactThreshold: serialized.ExactThreshold,
+        levelMult:      serialized.LevelMult,
+        deleteMode:     serialized.DeleteMode,
+        vacuumRatio:    serialized.VacuumRatio,
+        rng:            rand.New(rand.NewSource(rand.Int63())),
         mutex:          sync.RWMutex{},
     }
+    if h.levelMult <= 0 {
+        h.levelMult = defaultLevelMult(h.M)
+    }
+
+    // Older files stored the layer 0 limit in Mmax and used M above it
+    if h.Mmax0 == 0 {
+        h.Mmax0 = h.Mmax
+        h.Mmax = h.M
+    }
 
     // First pass: create all nodes
     for id, sNode := range serialized.Nodes {
@@ -315,13 +691,12 @@ func Load(filename string, distanceFunc DistanceFunc) (*HNSW, error) {
     for id, sNode := range serialized.Nodes {
         node := h.Nodes[id]
         for i, sLevel := range sNode.Levels {
-            level := &Level{
-                Connections: make([]*Node, len(sLevel.ConnectionIDs)),
-            }
+            conns := make([]*Node, len(sLevel.ConnectionIDs))
             for j, connID := range sLevel.ConnectionIDs {
-                level.Connections[j] = h.Nodes[connID]
+                conns[j] = h.Nodes[connID]
             }
-            node.Levels[i] = level
+            node.Levels[i] = &Level{}
+            node.setConnections(i, conns)
         }
 
         if id == serialized.EntryPointID {
@@ -329,15 +704,20 @@ func Load(filename string, distanceFunc DistanceFunc) (*HNSW, error) {
         }
     }
 
+    // Restore tombstones for nodes that are still in the graph
+    for id, deleted := range serialized.DeletedNodes {
+        if _, exists := h.Nodes[id]; exists && deleted {
+            h.deletedNodes[id] = true
+        }
+    }
+
     return h, nil
 }
 
 func (h *HNSW) randomLevel() int {
-    level := 0
-    for rand.Float64() < 0.5 && level < 32 {
-        level++
-    }
-    return level
+    // 1 - Float64() lies in (0, 1], so the logarithm is always finite
+    level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+    return min(level, 32)
 }
 
 func min(a, b int) int {
@@ -347,89 +727,176 @@ func min(a, b int) int {
     return b
 }
 
-func (h *HNSW) searchLayer(entryPoint *Node, vec Vector, ef int, level int) []*Node {
-    if level >= len(entryPoint.Levels) {
-        return []*Node{entryPoint}
-    }
+// searchOpts holds optional per-query behavior for layer searches
+type searchOpts struct {
+    // accept reports whether a node may fill a result slot. Rejected nodes are
+    // still used to navigate the graph. A nil accept admits every node.
+    accept func(*Node) bool
+    // ctx stops the traversal early once it is done. A nil ctx never stops it.
+    ctx context.Context
+    // stats collects traversal counters when set
+    stats *SearchStats
+    // minAcceptRate stops a traversal once it accepts a smaller share of the
+    // nodes it checks, so that a selective filter falls back to a scan
+    // early. Zero never stops it.
+    minAcceptRate     float64
+    checked, accepted int
+}
 
-    visited := sync.Map{}
-    visitedResults := sync.Map{} // Track visited nodes that are potential results
+// acceptSample is the number of nodes a traversal checks before it trusts
+// its accept rate
+const acceptSample = 256
 
-    // Initialize candidates with entry point
-    candidates := []*Node{entryPoint}
-    visited.Store(entryPoint.ID, true)
-
-    // Initialize result set
-    results := []*Node{entryPoint}
-    visitedResults.Store(entryPoint.ID, true)
+// accepts reports whether node may be returned as a result
+func (o *searchOpts) accepts(node *Node) bool {
+    if o == nil || o.accept == nil {
+        return true
+    }
+    ok := o.accept(node)
+    o.checked++
+    if ok {
+        o.accepted++
+    }
+    return ok
+}
 
-    // Calculate distance to entry point
-    entryDist := h.DistanceFunc(entryPoint.Vector, vec)
-    furthestDist := entryDist
+// sparse reports whether the traversal has checked enough nodes to tell that
+// it accepts too few of them to be worth continuing
+func (o *searchOpts) sparse() bool {
+    return o != nil && o.minAcceptRate > 0 && o.checked >= acceptSample &&
+        float64(o.accepted) < o.minAcceptRate*float64(o.checked)
+}
 
-    var resultsMutex sync.Mutex
+// err returns the context error once the traversal should stop, or nil
+func (o *searchOpts) err() error {
+    if o == nil || o.ctx == nil {
+        return nil
+    }
+    return o.ctx.Err()
+}
 
-    for len(candidates) > 0 {
-        // Get current candidate
-        currentNode := candidates[0]
-        currentDist := h.DistanceFunc(currentNode.Vector, vec)
-        candidates = candidates[1:]
+// searchLayer returns up to ef nearest neighbors of vec on the given level with
+// their distances, closest first, starting from the given entry points (paper Algorithm 2)
+func (h *HNSW) searchLayer(entryPoints []*Node, vec Vector, ef int, level int, opts *searchOpts) []*nodeDist {
+    distance := h.metric().distance
+    visited := make(map[int]bool)
+    candidates := &nodeDistMinHeap{}
+    resultSet := &nodeDistHeap{}
 
-        // If we've found something further than our worst candidate
-        if currentDist > furthestDist {
+    for _, entryPoint := range entryPoints {
+        if entryPoint == nil || visited[entryPoint.ID] {
             continue
         }
+        visited[entryPoint.ID] = true
+        opts.countVisit(1)
+
+        entryDist := distance(entryPoint.Vector, vec)
+        heap.Push(candidates, &nodeDist{entryPoint, entryDist})
+        if opts.accepts(entryPoint) {
+            heap.Push(resultSet, &nodeDist{entryPoint, entryDist})
+            if resultSet.Len() > ef {
+                heap.Pop(resultSet)
+            }
+        }
+    }
 
-        // Check neighbors
-        currentNode.RLock()
-        if level < len(currentNode.Levels) && currentNode.Levels[level] != nil {
-            for _, neighbor := range currentNode.Levels[level].Connections {
-                if neighbor == nil {
-                    continue
-                }
+    for candidates.Len() > 0 && opts.err() == nil && !opts.sparse() {
+        current := heap.Pop(candidates).(*nodeDist)
 
-                // Skip if already visited
-                if _, seen := visited.LoadOrStore(neighbor.ID, true); seen {
+        // Stop once the closest candidate is further than the worst result
+        if resultSet.Len() >= ef && current.dist > (*resultSet)[0].dist {
+            opts.countPruned(candidates.Len() + 1)
+            break
+        }
+
+        current.node.RLock()
+        opts.countHop(level)
+        if level < len(current.node.Levels) && current.node.Levels[level] != nil {
+            for _, neighbor := range current.node.Levels[level].Connections {
+                if neighbor == nil || visited[neighbor.ID] {
                     continue
                 }
+                visited[neighbor.ID] = true
+                opts.countVisit(1)
+
+                neighborDist := distance(neighbor.Vector, vec)
+                if resultSet.Len() < ef || neighborDist < (*resultSet)[0].dist {
+                    heap.Push(candidates, &nodeDist{neighbor, neighborDist})
+                    if opts.accepts(neighbor) {
+                        heap.Push(resultSet, &nodeDist{neighbor, neighborDist})
+                        if resultSet.Len() > ef {
+                            heap.Pop(resultSet)
+                        }
+                    }
+                } else {
+                    opts.countPruned(1)
+                }
+            }
+        }
+        current.node.RUnlock()
+    }
 
-                neighborDist := h.DistanceFunc(neighbor.Vector, vec)
+    results := make([]*nodeDist, resultSet.Len())
+    for i := len(results) - 1; i >= 0; i-- {
+        results[i] = heap.Pop(resultSet).(*nodeDist)
+    }
+    return results
+}
 
-                // Update results if this is a better candidate
-                resultsMutex.Lock()
-                if len(results) < ef || neighborDist < furthestDist {
-                    if _, seen := visitedResults.LoadOrStore(neighbor.ID, true); !seen {
-                        results = append(results, neighbor)
-                    }
+// searchLayerRadius returns every accepted base layer node within radius of
+// vec, closest first. Outside the radius the search behaves like an ef search
+// so that it can reach the radius from a distant entry point. The radius and
+// returned distances are ranking distances.
+func (h *HNSW) searchLayerRadius(entryPoint *Node, vec Vector, radius float64, ef int, opts *searchOpts) []*nodeDist {
+    distance := h.metric().distance
+    visited := map[int]bool{entryPoint.ID: true}
+    candidates := &nodeDistMinHeap{}
+    nearest := &nodeDistHeap{}
+    var found []*nodeDist
+
+    entryDist := distance(entryPoint.Vector, vec)
+    heap.Push(candidates, &nodeDist{entryPoint, entryDist})
+    heap.Push(nearest, &nodeDist{entryPoint, entryDist})
+    if entryDist <= radius && opts.accepts(entryPoint) {
+        found = append(found, &nodeDist{entryPoint, entryDist})
+    }
 
-                    // Sort results by distance
-                    sort.Slice(results, func(i, j int) bool {
-                        return h.DistanceFunc(results[i].Vector, vec) < h.DistanceFunc(results[j].Vector, vec)
-                    })
+    for candidates.Len() > 0 && opts.err() == nil {
+        current := heap.Pop(candidates).(*nodeDist)
 
-                    // Keep only ef closest results
-                    if len(results) > ef {
-                        results = results[:ef]
-                    }
+        // Stop once the frontier is outside the radius and no longer improving
+        if current.dist > radius && nearest.Len() >= ef && current.dist > (*nearest)[0].dist {
+            break
+        }
 
-                    // Update furthest distance
-                    furthestDist = h.DistanceFunc(results[len(results)-1].Vector, vec)
+        current.node.RLock()
+        if len(current.node.Levels) > 0 && current.node.Levels[0] != nil {
+            for _, neighbor := range current.node.Levels[0].Connections {
+                if neighbor == nil || visited[neighbor.ID] {
+                    continue
+                }
+                visited[neighbor.ID] = true
+
+                neighborDist := distance(neighbor.Vector, vec)
+                if neighborDist <= radius || nearest.Len() < ef || neighborDist < (*nearest)[0].dist {
+                    heap.Push(candidates, &nodeDist{neighbor, neighborDist})
+                    heap.Push(nearest, &nodeDist{neighbor, neighborDist})
+                    if nearest.Len() > ef {
+                        heap.Pop(nearest)
+                    }
+                }
+                if neighborDist <= radius && opts.accepts(neighbor) {
+                    found = append(found, &nodeDist{neighbor, neighborDist})
                 }
-                resultsMutex.Unlock()
-
-                // Add to candidates if it could lead to better results
-                candidates = append(candidates, neighbor)
             }
         }
-        currentNode.RUnlock()
-
-        // Sort candidates by distance
-        sort.Slice(candidates, func(i, j int) bool {
-            return h.DistanceFunc(candidates[i].Vector, vec) < h.DistanceFunc(candidates[j].Vector, vec)
-        })
+        current.node.RUnlock()
     }
 
-    return results
+    sort.Slice(found, func(i, j int) bool {
+        return found[i].dist < found[j].dist
+    })
+    return found
 }
 
 /*
@@ -439,9 +906,14 @@ This optimized version:
 3. Uses heap for efficient nearest neighbor tracking
 4. Handles contention with fine-grained locking
 */
-func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level int) []*Node {
+func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level int, opts *searchOpts) []*nodeDist {
+    metric := h.metric()
     if level >= len(entryPoint.Levels) {
-        return []*Node{entryPoint}
+        if !opts.accepts(entryPoint) {
+            return nil
+        }
+        opts.countVisit(1)
+        return []*nodeDist{{entryPoint, metric.distance(entryPoint.Vector, vec)}}
     }
 
     visited := sync.Map{}
@@ -451,15 +923,18 @@ func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level i
     heap.Init(candidates)
     heap.Init(resultSet)
 
-    entryDist := h.DistanceFunc(entryPoint.Vector, vec)
+    entryDist := metric.distance(entryPoint.Vector, vec)
+    opts.countVisit(1)
     heap.Push(candidates, &nodeDist{entryPoint, entryDist})
-    heap.Push(resultSet, &nodeDist{entryPoint, entryDist})
+    if opts.accepts(entryPoint) {
+        heap.Push(resultSet, &nodeDist{entryPoint, entryDist})
+    }
     visited.Store(entryPoint.ID, true)
     visitedResults.Store(entryPoint.ID, true)
 
     // Process in batches
     batchSize := 256
-    for candidates.Len() > 0 {
+    for candidates.Len() > 0 && opts.err() == nil && !opts.sparse() {
         // Collect candidates and their neighbors
         neighbors := make([]*Node, 0, batchSize*h.M)
         candidateNodes := make([]*Node, 0, batchSize)
@@ -468,6 +943,7 @@ func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level i
         for i := 0; i < batchSize && candidates.Len() > 0; i++ {
             node := heap.Pop(candidates).(*nodeDist).node
             candidateNodes = append(candidateNodes, node)
+            opts.countHop(level)
 
             node.RLock()
             if level < len(node.Levels) && node.Levels[level] != nil {
@@ -492,7 +968,8 @@ func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level i
                 copy(batchVectors[i], n.Vector)
             }
 
-            distances := BatchEuclidean(vec, batchVectors)
+            distances := metric.batch(vec, batchVectors)
+            opts.countVisit(len(distances))
 
             // Process results
             for i, dist := range distances {
@@ -500,19 +977,23 @@ func (h *HNSW) searchLayerParallel(entryPoint *Node, vec Vector, ef int, level i
                 if resultSet.Len() < ef || dist < (*resultSet)[0].dist {
                     if _, seen := visitedResults.LoadOrStore(node.ID, true); !seen {
                         heap.Push(candidates, &nodeDist{node, dist})
-                        heap.Push(resultSet, &nodeDist{node, dist})
-                        if resultSet.Len() > ef {
-                            heap.Pop(resultSet)
+                        if opts.accepts(node) {
+                            heap.Push(resultSet, &nodeDist{node, dist})
+                            if resultSet.Len() > ef {
+                                heap.Pop(resultSet)
+                            }
                         }
                     }
+                } else {
+                    opts.countPruned(1)
                 }
             }
         }
     }
 
-    results := make([]*Node, resultSet.Len())
+    results := make([]*nodeDist, resultSet.Len())
     for i := len(results) - 1; i >= 0; i-- {
-        results[i] = heap.Pop(resultSet).(*nodeDist).node
+        results[i] = heap.Pop(resultSet).(*nodeDist)
     }
     return results
 }
@@ -522,6 +1003,15 @@ type nodeDist struct {
     dist float64
 }
 
+// nodesOf strips the distances from a layer search result
+func nodesOf(results []*nodeDist) []*Node {
+    nodes := make([]*Node, len(results))
+    for i, r := range results {
+        nodes[i] = r.node
+    }
+    return nodes
+}
+
 type nodeDistHeap []*nodeDist
 
 func (h nodeDistHeap) Len() int            { return len(h) }
@@ -536,6 +1026,21 @@ func (h *nodeDistHeap) Pop() interface{} {
     return x
 }
 
+// nodeDistMinHeap pops the closest node first
+type nodeDistMinHeap []*nodeDist
+
+func (h nodeDistMinHeap) Len() int            { return len(h) }
+func (h nodeDistMinHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h nodeDistMinHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeDistMinHeap) Push(x interface{}) { *h = append(*h, x.(*nodeDist)) }
+func (h *nodeDistMinHeap) Pop() interface{} {
+    old := *h
+    n := len(old)
+    x := old[n-1]
+    *h = old[0 : n-1]
+    return x
+}
+
 func (h *HNSW) addConnection(node, newNode *Node, level int) {
     node.Lock()
     defer node.Unlock()
@@ -546,10 +1051,7 @@ func (h *HNSW) addConnection(node, newNode *Node, level int) {
     }
 
     // Get max connections for this level
-    maxConnections := h.M
-    if level == 0 {
-        maxConnections = h.Mmax
-    }
+    maxConnections := h.maxConnections(level)
 
     // Check if connection already exists
     for _, conn := range node.Levels[level].Connections {
@@ -558,49 +1060,52 @@ func (h *HNSW) addConnection(node, newNode *Node, level int) {
         }
     }
 
-    // Calculate distances for all connections including the new one
-    type connDist struct {
-        node     *Node
-        distance float64
-    }
-
-    conns := make([]connDist, 0, len(node.Levels[level].Connections)+1)
+    conns := make([]*Node, 0, len(node.Levels[level].Connections)+1)
+    conns = append(conns, node.Levels[level].Connections...)
+    conns = append(conns, newNode)
 
-    // Add existing connections
-    for _, conn := range node.Levels[level].Connections {
-        dist := h.DistanceFunc(node.Vector, conn.Vector)
-        conns = append(conns, connDist{conn, dist})
+    // Shrink the neighborhood with the configured selection strategy
+    if len(conns) > maxConnections {
+        conns = h.selectNeighbors(node, conns, maxConnections, level)
     }
+    node.setConnections(level, conns)
+}
 
-    // Add new connection
-    newDist := h.DistanceFunc(node.Vector, newNode.Vector)
-    conns = append(conns, connDist{newNode, newDist})
-
-    // Sort by distance
-    sort.Slice(conns, func(i, j int) bool {
-        return conns[i].distance < conns[j].distance
-    })
-
-    // Keep only the closest connections up to maxConnections
-    node.Levels[level].Connections = make([]*Node, 0, maxConnections)
-    for i := 0; i < len(conns) && i < maxConnections; i++ {
-        node.Levels[level].Connections = append(node.Levels[level].Connections, conns[i].node)
+// maxConnections returns the connection limit for a level
+func (h *HNSW) maxConnections(level int) int {
+    if level == 0 {
+        return h.Mmax0
     }
+    return h.Mmax
+}
 
-    // Optional: Keep some long-range connections for better graph navigability
-    if level > 0 && len(conns) > maxConnections {
-        // Replace one close connection with a longer-range one
-        longRangeIdx := len(conns) - 1
-        if rand.Float64() < 0.2 { // 20% chance to keep a long-range connection
-            node.Levels[level].Connections[maxConnections-1] = conns[longRangeIdx].node
-        }
+// selectNeighbors picks at most m neighbors for base using the index's selector
+func (h *HNSW) selectNeighbors(base *Node, candidates []*Node, m, level int) []*Node {
+    selector := h.Selector
+    if selector == nil {
+        selector = HeuristicSelector{}
     }
+    return selector.SelectNeighbors(base, candidates, m, level, h.metric().distance)
 }
 
 // SearchConfig contains search parameters
 type SearchConfig struct {
     UseParallel bool
     WorkerCount int
+    Ef          int // Size of the base layer candidate list, 0 uses the index default
+
+    // Filter restricts results to IDs it returns true for. Non-matching nodes
+    // are still traversed. It is called under the index read lock and must
+    // not call back into the index.
+    Filter func(id int) bool
+
+    // Exclude lists IDs that never appear in the results. Like filtered
+    // nodes, excluded nodes are traversed but don't take result slots.
+    Exclude map[int]bool
+
+    // Offset skips the first Offset results so that a client can page
+    // through neighbors: k results at offset 10 are results 11 to 10+k.
+    Offset int
 }
 
 // DefaultSearchConfig returns default search configuration
@@ -611,72 +1116,180 @@ func DefaultSearchConfig() SearchConfig {
     }
 }
 
+// SetEf sets the default size of the base layer candidate list used by searches
+// that don't set SearchConfig.Ef. A value of 0 restores the default of k*2.
+func (h *HNSW) SetEf(ef int) {
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+    h.efSearch = ef
+}
+
+// searchEf returns the candidate list size for a k nearest neighbor search
+func (h *HNSW) searchEf(k int, config SearchConfig) int {
+    ef := config.Ef
+    if ef <= 0 {
+        ef = h.efSearch
+    }
+    if ef <= 0 {
+        ef = k * 2
+    }
+    return max(ef, k)
+}
+
+// SearchResult is a search hit with its distance to the query
+type SearchResult struct {
+    ID       int
+    Distance float64
+}
+
 // Search finds k nearest neighbors using default config (parallel)
-func (h *HNSW) Search(vec Vector, k int) []int {
+func (h *HNSW) Search(vec Vector, k int) ([]int, error) {
     return h.SearchWithConfig(vec, k, DefaultSearchConfig())
 }
 
 // SearchWithConfig finds k nearest neighbors with custom config
-func (h *HNSW) SearchWithConfig(vec Vector, k int, config SearchConfig) []int {
+func (h *HNSW) SearchWithConfig(vec Vector, k int, config SearchConfig) ([]int, error) {
+    results, err := h.SearchWithDistances(vec, k, config)
+    if err != nil {
+        return nil, err
+    }
+
+    ids := make([]int, len(results))
+    for i, r := range results {
+        ids[i] = r.ID
+    }
+    return ids, nil
+}
+
+// SearchWithDistances finds k nearest neighbors with custom config and
+// returns them with their distances, closest first
+func (h *HNSW) SearchWithDistances(vec Vector, k int, config SearchConfig) ([]SearchResult, error) {
+    return h.SearchContext(context.Background(), vec, k, config)
+}
+
+// SearchContext is like SearchWithDistances but checks ctx between traversal
+// steps. Once ctx is done it returns the best results found so far, which may
+// be fewer than k, along with ctx.Err().
+func (h *HNSW) SearchContext(ctx context.Context, vec Vector, k int, config SearchConfig) ([]SearchResult, error) {
+    if err := h.validateVector(vec); err != nil {
+        return nil, err
+    }
+    if err := ctx.Err(); err != nil {
+        return []SearchResult{}, err
+    }
+
     h.mutex.RLock()
     defer h.mutex.RUnlock()
+    return h.search(ctx, vec, k, config, nil)
+}
 
-    if len(h.Nodes) == 0 || h.EntryPoint == nil {
-        return []int{}
+// SearchRadius returns the indexed vectors within radius of vec, closest first,
+// keeping at most limit results (limit <= 0 keeps all). The base layer search
+// keeps expanding until its whole frontier lies outside the radius.
+func (h *HNSW) SearchRadius(vec Vector, radius float64, limit int) ([]SearchResult, error) {
+    if err := h.validateVector(vec); err != nil {
+        return nil, err
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    // Radii may be negative for metrics such as InnerProduct, but not for a
+    // metric ranked by its square, which would turn them positive
+    metric := h.metric()
+    if len(h.Nodes) == 0 || h.EntryPoint == nil || math.IsNaN(radius) || (metric.squared && radius < 0) {
+        return []SearchResult{}, nil
+    }
+
+    currentNode := h.greedySearch(h.EntryPoint, vec, h.MaxLevel, 1, nil)
+    ef := h.searchEf(max(limit, 1), SearchConfig{})
+    opts := &searchOpts{accept: h.acceptFunc(SearchConfig{})}
+    found := h.searchLayerRadius(currentNode, vec, metric.rankDistance(radius), ef, opts)
+
+    if limit > 0 && len(found) > limit {
+        found = found[:limit]
     }
+    return h.toSearchResults(found, len(found)), nil
+}
 
-    // Get entry point
-    currentNode := h.EntryPoint
+// search returns the k nearest live neighbors of vec, or the best found so far
+// along with ctx.Err() once ctx is done. Traversal counters are added to stats
+// if it is not nil. The caller must hold the read lock.
+func (h *HNSW) search(ctx context.Context, vec Vector, k int, config SearchConfig, stats *SearchStats) ([]SearchResult, error) {
+    if len(h.Nodes) == 0 || h.EntryPoint == nil || k <= 0 {
+        return []SearchResult{}, nil
+    }
 
     // Search through levels
-    for level := h.MaxLevel; level > 0; level-- {
-        changed := true
-        for changed {
-            changed = false
-            currentNode.RLock()
-            if level < len(currentNode.Levels) && currentNode.Levels[level] != nil {
-                for _, neighbor := range currentNode.Levels[level].Connections {
-                    if h.DistanceFunc(neighbor.Vector, vec) < h.DistanceFunc(currentNode.Vector, vec) {
-                        currentNode.RUnlock()
-                        currentNode = neighbor
-                        changed = true
-                        break
-                    }
-                }
-            }
-            if !changed {
-                currentNode.RUnlock()
-            }
-        }
+    opts := &searchOpts{accept: h.acceptFunc(config), ctx: ctx, stats: stats}
+    currentNode := h.greedySearch(h.EntryPoint, vec, h.MaxLevel, 1, opts)
+
+    // A page at an offset needs the results before it to rank its own
+    offset := max(config.Offset, 0)
+    results, err := h.searchFrom(currentNode, vec, k+offset, config, opts)
+    if offset >= len(results) {
+        return []SearchResult{}, err
     }
+    return results[offset:], err
+}
 
-    // Search base layer
-    var candidates []*Node
+// searchFrom searches the base layer from entry for the k nearest accepted
+// neighbors of vec, switching to a scan when too few nodes are accepted.
+// Indexes below the exact search threshold are scanned instead.
+// The caller must hold the read lock.
+func (h *HNSW) searchFrom(entry *Node, vec Vector, k int, config SearchConfig, opts *searchOpts) ([]SearchResult, error) {
+    // Small indexes skip the approximation altogether
+    if len(h.Nodes)-len(h.deletedNodes) < h.exactThreshold {
+        return h.scan(vec, k, opts)
+    }
+
+    // Filling ef results at accept rate r takes about ef/r visits. Below the
+    // rate where that covers a quarter of the index, a scan is cheaper.
+    ef := h.searchEf(k, config)
+    if opts.accept != nil {
+        opts.minAcceptRate = 4 * float64(ef) / float64(max(len(h.Nodes), 1))
+    }
+
+    // Candidates come back sorted by distance
+    var candidates []*nodeDist
     if config.UseParallel {
-        candidates = h.searchLayerParallel(currentNode, vec, k*2, 0) // Double ef for better accuracy
+        candidates = h.searchLayerParallel(entry, vec, ef, 0, opts)
     } else {
-        candidates = h.searchLayer(currentNode, vec, k*2, 0)
+        candidates = h.searchLayer([]*Node{entry}, vec, ef, 0, opts)
     }
 
-    // Filter deleted nodes
-    validCandidates := make([]*Node, 0, len(candidates))
-    for _, node := range candidates {
-        if !h.deletedNodes[node.ID] {
-            validCandidates = append(validCandidates, node)
-        }
+    if err := opts.err(); err != nil {
+        return h.toSearchResults(candidates, k), err
     }
 
-    // Sort by distance
-    sort.Slice(validCandidates, func(i, j int) bool {
-        return h.DistanceFunc(validCandidates[i].Vector, vec) < h.DistanceFunc(validCandidates[j].Vector, vec)
-    })
+    // A traversal that gave up on a selective filter, or that ran out of
+    // nodes before filling k slots, has nothing a wider ef would add
+    if opts.accept != nil && (opts.sparse() || len(candidates) < k) {
+        return h.scan(vec, k, opts)
+    }
+    return h.toSearchResults(candidates, k), nil
+}
 
-    // Return k closest
-    count := min(k, len(validCandidates))
-    result := make([]int, count)
-    for i := 0; i < count; i++ {
-        result[i] = validCandidates[i].ID
+// acceptFunc returns the predicate for nodes that may appear in the results
+// of a search, or nil if every node may
+func (h *HNSW) acceptFunc(config SearchConfig) func(*Node) bool {
+    if len(h.deletedNodes) == 0 && config.Filter == nil && len(config.Exclude) == 0 {
+        return nil
+    }
+    return func(node *Node) bool {
+        return !h.deletedNodes[node.ID] && !config.Exclude[node.ID] &&
+            (config.Filter == nil || config.Filter(node.ID))
     }
+}
 
-    return result
+// toSearchResults converts the k closest layer search results, turning
+// ranking distances into metric distances
+func (h *HNSW) toSearchResults(candidates []*nodeDist, k int) []SearchResult {
+    metric := h.metric()
+    count := min(k, len(candidates))
+    results := make([]SearchResult, count)
+    for i := 0; i < count; i++ {
+        results[i] = SearchResult{ID: candidates[i].node.ID, Distance: metric.trueDistance(candidates[i].dist)}
+    }
+    return results
 }
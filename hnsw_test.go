@@ -2,17 +2,21 @@
 package hnsw
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"testing"
 )
 
 func TestNew(t *testing.T) {
 	h := New(128, 16, 32, 100, Euclidean)
-	if h.Dim != 128 || h.M != 16 || h.Mmax != 32 || h.EfConstruction != 100 {
+	if h.Dim != 128 || h.M != 16 || h.Mmax != 16 || h.Mmax0 != 32 || h.EfConstruction != 100 {
 		t.Errorf("New() created HNSW with incorrect parameters")
 	}
 }
@@ -37,7 +41,10 @@ func TestInsertAndSearch(t *testing.T) {
 	config := SearchConfig{
 		UseParallel: false, // Use sequential search for stability in tests
 	}
-	results := h.SearchWithConfig(query, 2, config)
+	results, err := h.SearchWithConfig(query, 2, config)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
 
 	if len(results) != 2 {
 		t.Fatalf("Search() returned %d results; want 2", len(results))
@@ -91,7 +98,10 @@ func TestSearch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			config := SearchConfig{UseParallel: false}
-			got := h.SearchWithConfig(tt.query, tt.k, config)
+			got, err := h.SearchWithConfig(tt.query, tt.k, config)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
 
 			if len(got) != len(tt.wantIDs) {
 				t.Errorf("got %v results, want %v", len(got), len(tt.wantIDs))
@@ -126,7 +136,10 @@ func TestParallelSearch(t *testing.T) {
 		UseParallel: true,
 		WorkerCount: runtime.NumCPU(),
 	}
-	results := h.SearchWithConfig(query, 4, config)
+	results, err := h.SearchWithConfig(query, 4, config)
+	if err != nil {
+		t.Fatalf("Parallel search error = %v", err)
+	}
 
 	if len(results) == 0 {
 		t.Error("Parallel search returned no results")
@@ -143,6 +156,75 @@ func TestParallelSearch(t *testing.T) {
 	}
 }
 
+// TestSearchEf verifies ef selection for searches
+func TestSearchEf(t *testing.T) {
+	h := New(2, 16, 32, 100, Euclidean)
+
+	tests := []struct {
+		name     string
+		indexEf  int
+		configEf int
+		k        int
+		want     int
+	}{
+		{"Default doubles k", 0, 0, 5, 10},
+		{"Index default", 50, 0, 5, 50},
+		{"Config overrides index", 50, 200, 10, 200},
+		{"Never below k", 50, 3, 10, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.SetEf(tt.indexEf)
+			if got := h.searchEf(tt.k, SearchConfig{Ef: tt.configEf}); got != tt.want {
+				t.Errorf("searchEf() = %d; want %d", got, tt.want)
+			}
+		})
+	}
+
+	// A larger ef must still return exactly k results
+	for i := 0; i < 100; i++ {
+		h.Insert(i, Vector{float64(i / 10), float64(i % 10)})
+	}
+	h.SetEf(64)
+	for _, parallel := range []bool{false, true} {
+		results := search(t, h, Vector{4.5, 4.5}, 1, SearchConfig{UseParallel: parallel})
+		if len(results) != 1 {
+			t.Errorf("parallel=%v: got %d results, want 1", parallel, len(results))
+		}
+	}
+}
+
+// TestSearchWithDistances verifies returned distances match the metric
+func TestSearchWithDistances(t *testing.T) {
+	h := New(2, 16, 32, 100, Euclidean)
+	for i := 0; i < 100; i++ {
+		h.Insert(i, Vector{float64(i / 10), float64(i % 10)})
+	}
+
+	query := Vector{3.2, 6.7}
+	for _, parallel := range []bool{false, true} {
+		results, err := h.SearchWithDistances(query, 5, SearchConfig{UseParallel: parallel})
+		if err != nil {
+			t.Fatalf("parallel=%v: SearchWithDistances() error = %v", parallel, err)
+		}
+		if len(results) != 5 {
+			t.Fatalf("parallel=%v: got %d results, want 5", parallel, len(results))
+		}
+		if results[0].ID != 37 {
+			t.Errorf("parallel=%v: closest = %d; want 37", parallel, results[0].ID)
+		}
+		for i, r := range results {
+			if want := Euclidean(h.Nodes[r.ID].Vector, query); math.Abs(r.Distance-want) > 1e-12 {
+				t.Errorf("parallel=%v: result %d distance = %v; want %v", parallel, i, r.Distance, want)
+			}
+			if i > 0 && r.Distance < results[i-1].Distance {
+				t.Errorf("parallel=%v: results not sorted at %d", parallel, i)
+			}
+		}
+	}
+}
+
 // TestConcurrentSearches verifies concurrent search safety
 func TestConcurrentSearches(t *testing.T) {
 	h := New(2, 16, 32, 100, Euclidean)
@@ -163,18 +245,22 @@ func TestConcurrentSearches(t *testing.T) {
 	}
 
 	results := make([][]int, len(queries))
+	errs := make([]error, len(queries))
 	config := DefaultSearchConfig()
 
 	for i := range queries {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			results[idx] = h.SearchWithConfig(queries[idx], 5, config)
+			results[idx], errs[idx] = h.SearchWithConfig(queries[idx], 5, config)
 		}(i)
 	}
 	wg.Wait()
 
 	for i, r := range results {
+		if errs[i] != nil {
+			t.Errorf("Query %d: error = %v", i, errs[i])
+		}
 		if len(r) != 5 {
 			t.Errorf("Query %d: got %d results, want 5", i, len(r))
 			t.Logf("Query point: %v", queries[i])
@@ -185,6 +271,196 @@ func TestConcurrentSearches(t *testing.T) {
 	}
 }
 
+// search runs SearchWithConfig and fails the test on error
+func search(t *testing.T, h *HNSW, vec Vector, k int, config SearchConfig) []int {
+	t.Helper()
+	results, err := h.SearchWithConfig(vec, k, config)
+	if err != nil {
+		t.Fatalf("SearchWithConfig(%v, %d) error = %v", vec, k, err)
+	}
+	return results
+}
+
+// bruteForce returns the IDs of the k vectors closest to query
+func bruteForce(vectors []Vector, query Vector, k int) []int {
+	ids := make([]int, len(vectors))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return Euclidean(vectors[ids[i]], query) < Euclidean(vectors[ids[j]], query)
+	})
+	return ids[:k]
+}
+
+// recall returns the fraction of want found in got
+func recall(got, want []int) float64 {
+	found := make(map[int]bool, len(got))
+	for _, id := range got {
+		found[id] = true
+	}
+	hits := 0
+	for _, id := range want {
+		if found[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(want))
+}
+
+// TestInsertEntryPoint verifies the entry point always sits on the top level
+func TestInsertEntryPoint(t *testing.T) {
+	h := New(3, 8, 16, 64, Euclidean)
+	rng := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 500; i++ {
+		h.Insert(i, Vector{rng.Float64(), rng.Float64(), rng.Float64()})
+		if len(h.EntryPoint.Levels)-1 != h.MaxLevel {
+			t.Fatalf("after insert %d: entry point has %d levels, index max level is %d",
+				i, len(h.EntryPoint.Levels), h.MaxLevel)
+		}
+	}
+
+	for id, node := range h.Nodes {
+		if node.MaxLevel != len(node.Levels)-1 {
+			t.Errorf("node %d: MaxLevel = %d with %d levels", id, node.MaxLevel, len(node.Levels))
+		}
+	}
+}
+
+// TestInsertRecall verifies construction with efConstruction finds true neighbors
+func TestInsertRecall(t *testing.T) {
+	h := New(3, 8, 16, 100, Euclidean)
+	rng := rand.New(rand.NewSource(3))
+
+	vectors := make([]Vector, 2000)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64(), rng.Float64()}
+		h.Insert(i, vectors[i])
+	}
+
+	config := SearchConfig{UseParallel: false, Ef: 50}
+	var total float64
+	queries := 50
+	for q := 0; q < queries; q++ {
+		query := Vector{rng.Float64(), rng.Float64(), rng.Float64()}
+		total += recall(search(t, h, query, 10, config), bruteForce(vectors, query, 10))
+	}
+
+	if got := total / float64(queries); got < 0.95 {
+		t.Errorf("recall@10 = %.3f; want >= 0.95", got)
+	}
+}
+
+// graphSignature lists every node's connections by ID, level by level
+func graphSignature(h *HNSW) map[int][][]int {
+	sig := make(map[int][][]int, len(h.Nodes))
+	for id, node := range h.Nodes {
+		levels := make([][]int, len(node.Levels))
+		for i, level := range node.Levels {
+			for _, conn := range level.Connections {
+				levels[i] = append(levels[i], conn.ID)
+			}
+		}
+		sig[id] = levels
+	}
+	return sig
+}
+
+// TestSeededBuild verifies that a seeded build is reproducible
+func TestSeededBuild(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	vectors := make([]Vector, 300)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64()}
+	}
+
+	build := func() *HNSW {
+		h := New(2, 4, 8, 32, Euclidean)
+		h.SetSeed(42)
+		for i, v := range vectors {
+			h.Insert(i, v)
+		}
+		return h
+	}
+
+	h1, h2 := build(), build()
+	if h1.EntryPoint.ID != h2.EntryPoint.ID || h1.MaxLevel != h2.MaxLevel {
+		t.Fatalf("entry points differ: %d@%d vs %d@%d",
+			h1.EntryPoint.ID, h1.MaxLevel, h2.EntryPoint.ID, h2.MaxLevel)
+	}
+	if !reflect.DeepEqual(graphSignature(h1), graphSignature(h2)) {
+		t.Error("graphs built with the same seed differ")
+	}
+}
+
+// TestSquaredRanking verifies that ranking Euclidean indexes by squared
+// distance builds the same graph and reports true distances
+func TestSquaredRanking(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+	vectors := make([]Vector, 300)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64()}
+	}
+
+	// A wrapper isn't recognized as Euclidean, so it ranks by true distance
+	plain := func(v1, v2 Vector) float64 { return Euclidean(v1, v2) }
+	build := func(distanceFunc DistanceFunc) *HNSW {
+		h := New(2, 4, 8, 32, distanceFunc)
+		h.SetSeed(42)
+		for i, v := range vectors {
+			h.Insert(i, v)
+		}
+		return h
+	}
+
+	squared, unsquared := build(Euclidean), build(plain)
+	if !squared.metric().squared || unsquared.metric().squared {
+		t.Fatal("only the Euclidean index should rank by squared distance")
+	}
+	if !reflect.DeepEqual(graphSignature(squared), graphSignature(unsquared)) {
+		t.Error("squared ranking built a different graph")
+	}
+
+	query := Vector{0.4, 0.6}
+	config := SearchConfig{Ef: 32}
+	got, _ := squared.SearchWithDistances(query, 10, config)
+	want, _ := unsquared.SearchWithDistances(query, 10, config)
+	for i := range want {
+		if got[i].ID != want[i].ID || math.Abs(got[i].Distance-want[i].Distance) > 1e-12 {
+			t.Errorf("result %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestLevelMultiplier verifies levels follow the configured multiplier
+func TestLevelMultiplier(t *testing.T) {
+	h := New(2, 16, 32, 100, Euclidean)
+	h.SetSeed(1)
+	if want := 1 / math.Log(16); h.levelMult != want {
+		t.Errorf("default levelMult = %v; want %v", h.levelMult, want)
+	}
+
+	// With mL = 1/ln(M) a node reaches level 1 with probability 1/M
+	n := 100000
+	upper := 0
+	for i := 0; i < n; i++ {
+		if h.randomLevel() > 0 {
+			upper++
+		}
+	}
+	if got := float64(upper) / float64(n); math.Abs(got-1.0/16) > 0.005 {
+		t.Errorf("P(level > 0) = %.4f; want %.4f", got, 1.0/16)
+	}
+
+	h.SetLevelMultiplier(0.001)
+	for i := 0; i < 1000; i++ {
+		if level := h.randomLevel(); level != 0 {
+			t.Fatalf("randomLevel() = %d with tiny multiplier; want 0", level)
+		}
+	}
+}
+
 // TestDelete verifies deletion functionality
 func TestDelete(t *testing.T) {
 	h := New(2, 16, 32, 100, Euclidean)
@@ -198,7 +474,10 @@ func TestDelete(t *testing.T) {
 
 	// Initial search
 	query := Vector{1.1, 1.1}
-	initialResults := h.SearchWithConfig(query, 1, config)
+	initialResults, err := h.SearchWithConfig(query, 1, config)
+	if err != nil {
+		t.Fatalf("Initial search error = %v", err)
+	}
 	if len(initialResults) == 0 {
 		t.Fatal("Initial search returned no results")
 	}
@@ -207,10 +486,18 @@ func TestDelete(t *testing.T) {
 	}
 
 	// Delete vector
-	h.Delete(1)
+	if err := h.Delete(1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := h.Delete(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v; want ErrNotFound", err)
+	}
 
 	// Search after deletion
-	results := h.SearchWithConfig(query, 1, config)
+	results, err := h.SearchWithConfig(query, 1, config)
+	if err != nil {
+		t.Fatalf("Search() after delete error = %v", err)
+	}
 	if len(results) == 0 {
 		t.Error("Search() should return results after delete")
 	} else if results[0] == 1 {
@@ -225,6 +512,276 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// TestDeleteRepair verifies deleted nodes are unlinked and recall holds under churn
+// checkInbound verifies every node's inbound lists mirror the links pointing at it
+func checkInbound(t *testing.T, h *HNSW) {
+	t.Helper()
+	want := make(map[*Node][]map[*Node]int)
+	for _, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				for len(want[conn]) <= level {
+					want[conn] = append(want[conn], map[*Node]int{})
+				}
+				want[conn][level][node]++
+			}
+		}
+	}
+	for id, node := range h.Nodes {
+		for level, links := range node.inbound {
+			got := map[*Node]int{}
+			for _, from := range links {
+				got[from]++
+			}
+			var expected map[*Node]int
+			if level < len(want[node]) {
+				expected = want[node][level]
+			}
+			if len(got) != len(expected) {
+				t.Fatalf("node %d level %d: %d inbound links; want %d", id, level, len(got), len(expected))
+			}
+			for from, n := range expected {
+				if got[from] != n {
+					t.Fatalf("node %d level %d: missing inbound link from %d", id, level, from.ID)
+				}
+			}
+		}
+	}
+}
+
+// TestDeleteInbound verifies single deletes find every linking node through
+// the inbound lists, even in dimensions where links are often one-way
+func TestDeleteInbound(t *testing.T) {
+	h := New(32, 8, 16, 64, Euclidean)
+	h.SetSeed(6)
+	rng := rand.New(rand.NewSource(6))
+	randomVector := func() Vector {
+		v := make(Vector, 32)
+		for i := range v {
+			v[i] = rng.Float64()
+		}
+		return v
+	}
+
+	for i := 0; i < 1000; i++ {
+		h.Insert(i, randomVector())
+	}
+	for i := 0; i < 1000; i += 5 {
+		h.Update(i, randomVector())
+	}
+	checkInbound(t, h)
+
+	for i := 1; i < 1000; i += 3 {
+		h.Delete(i)
+	}
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				if h.Nodes[conn.ID] != conn {
+					t.Fatalf("node %d level %d still links to deleted node %d", id, level, conn.ID)
+				}
+			}
+		}
+	}
+	checkInbound(t, h)
+}
+
+func TestDeleteRepair(t *testing.T) {
+	h := New(3, 8, 16, 100, Euclidean)
+	h.SetSeed(5)
+	rng := rand.New(rand.NewSource(5))
+
+	vectors := make([]Vector, 1000)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64(), rng.Float64()}
+		h.Insert(i, vectors[i])
+	}
+
+	// Delete every other node, including whichever node is the entry point
+	h.Delete(h.EntryPoint.ID)
+	for i := 0; i < len(vectors); i += 2 {
+		h.Delete(i)
+	}
+
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				if h.Nodes[conn.ID] != conn {
+					t.Fatalf("node %d level %d still links to deleted node %d", id, level, conn.ID)
+				}
+			}
+		}
+	}
+	if h.Nodes[h.EntryPoint.ID] != h.EntryPoint || len(h.EntryPoint.Levels)-1 != h.MaxLevel {
+		t.Fatalf("entry point %d is not a live node on the top level", h.EntryPoint.ID)
+	}
+
+	live := make([]Vector, 0, len(h.Nodes))
+	ids := make([]int, 0, len(h.Nodes))
+	for i, v := range vectors {
+		if _, ok := h.Nodes[i]; ok {
+			live = append(live, v)
+			ids = append(ids, i)
+		}
+	}
+
+	config := SearchConfig{UseParallel: false, Ef: 50}
+	var total float64
+	queries := 50
+	for q := 0; q < queries; q++ {
+		query := Vector{rng.Float64(), rng.Float64(), rng.Float64()}
+		want := bruteForce(live, query, 10)
+		for i := range want {
+			want[i] = ids[want[i]]
+		}
+		total += recall(search(t, h, query, 10, config), want)
+	}
+	if got := total / float64(queries); got < 0.95 {
+		t.Errorf("recall@10 after deletes = %.3f; want >= 0.95", got)
+	}
+
+	// Deleting everything leaves an empty index that accepts new vectors
+	for id := range h.Nodes {
+		h.Delete(id)
+	}
+	if h.EntryPoint != nil || len(h.Nodes) != 0 {
+		t.Fatal("index not empty after deleting every node")
+	}
+	h.Insert(1, Vector{1, 1, 1})
+	if results := search(t, h, Vector{1, 1, 1}, 1, DefaultSearchConfig()); len(results) != 1 || results[0] != 1 {
+		t.Errorf("Search() after re-insert = %v; want [1]", results)
+	}
+}
+
+// TestUpdateUpsert verifies replaced vectors are relinked under the same ID
+func TestUpdateUpsert(t *testing.T) {
+	h := New(2, 8, 16, 64, Euclidean)
+	for i := 0; i < 100; i++ {
+		h.Insert(i, Vector{float64(i / 10), float64(i % 10)})
+	}
+	config := SearchConfig{UseParallel: false, Ef: 32}
+
+	old := h.Nodes[55]
+	if err := h.Update(55, Vector{50, 50}); err != nil {
+		t.Fatalf("Update() of existing ID error = %v", err)
+	}
+	if err := h.Update(1000, Vector{1, 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() of unknown ID error = %v; want ErrNotFound", err)
+	}
+	if _, exists := h.Nodes[1000]; exists {
+		t.Error("Update() of unknown ID inserted it")
+	}
+
+	// The node moves in place rather than being replaced
+	if h.Nodes[55] != old || old.Vector[0] != 50 {
+		t.Fatalf("Update() replaced node 55 instead of moving it")
+	}
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				if conn == node || h.Nodes[conn.ID] != conn {
+					t.Fatalf("node %d level %d has invalid link to %d", id, level, conn.ID)
+				}
+			}
+		}
+	}
+	if results := search(t, h, Vector{5, 5}, 1, config); len(results) != 1 || results[0] == 55 {
+		t.Errorf("Search() at old position = %v; want a different node", results)
+	}
+	if results := search(t, h, Vector{49, 49}, 1, config); len(results) != 1 || results[0] != 55 {
+		t.Errorf("Search() at new position = %v; want [55]", results)
+	}
+
+	if replaced, err := h.Upsert(200, Vector{-5, -5}); err != nil || replaced {
+		t.Errorf("Upsert() of new ID = %v, %v; want false, nil", replaced, err)
+	}
+	if replaced, err := h.Upsert(200, Vector{-6, -6}); err != nil || !replaced {
+		t.Errorf("Upsert() of existing ID = %v, %v; want true, nil", replaced, err)
+	}
+	if len(h.Nodes) != 101 {
+		t.Errorf("got %d nodes, want 101", len(h.Nodes))
+	}
+	if results := search(t, h, Vector{-6, -6}, 1, config); len(results) != 1 || results[0] != 200 {
+		t.Errorf("Search() = %v; want [200]", results)
+	}
+}
+
+// TestUpdateRecall verifies moved nodes stay reachable after many updates
+func TestUpdateRecall(t *testing.T) {
+	h := New(4, 8, 16, 64, Euclidean)
+	h.SetSeed(5)
+	rng := rand.New(rand.NewSource(5))
+	randomVector := func() Vector {
+		return Vector{rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64()}
+	}
+
+	vectors := make([]Vector, 300)
+	for i := range vectors {
+		vectors[i] = randomVector()
+		h.Insert(i, vectors[i])
+	}
+	for i := 0; i < len(vectors); i += 2 {
+		vectors[i] = randomVector()
+		if err := h.Update(i, vectors[i]); err != nil {
+			t.Fatalf("Update(%d) error = %v", i, err)
+		}
+	}
+
+	config := SearchConfig{Ef: 64}
+	found := 0
+	for i, v := range vectors {
+		if results := search(t, h, v, 1, config); len(results) == 1 && results[0] == i {
+			found++
+		}
+	}
+	if found < len(vectors)*98/100 {
+		t.Errorf("found %d of %d updated vectors; want at least 98%%", found, len(vectors))
+	}
+}
+
+// TestInvalidInput verifies malformed input is rejected with typed errors
+func TestInvalidInput(t *testing.T) {
+	h := New(3, 16, 32, 100, Euclidean)
+	if err := h.Insert(1, Vector{1, 2, 3}); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	cosine := New(3, 16, 32, 100, Cosine)
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"Short vector", h.Insert(2, Vector{1, 2}), ErrDimensionMismatch},
+		{"Long vector", h.Insert(2, Vector{1, 2, 3, 4}), ErrDimensionMismatch},
+		{"NaN component", h.Insert(2, Vector{1, math.NaN(), 3}), ErrInvalidVector},
+		{"Inf component", h.Insert(2, Vector{math.Inf(-1), 2, 3}), ErrInvalidVector},
+		{"Zero vector under Cosine", cosine.Insert(2, Vector{0, 0, 0}), ErrInvalidVector},
+		{"Duplicate ID", h.Insert(1, Vector{3, 2, 1}), ErrDuplicateID},
+		{"Update unknown ID", h.Update(2, Vector{3, 2, 1}), ErrNotFound},
+		{"Update invalid vector", h.Update(1, Vector{1}), ErrDimensionMismatch},
+		{"Delete unknown ID", h.Delete(2), ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) {
+				t.Errorf("error = %v; want %v", tt.err, tt.want)
+			}
+		})
+	}
+
+	if _, err := h.Search(Vector{1, 2}, 1); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Search() error = %v; want ErrDimensionMismatch", err)
+	}
+	if _, err := h.Upsert(3, Vector{math.NaN(), 0, 0}); !errors.Is(err, ErrInvalidVector) {
+		t.Errorf("Upsert() error = %v; want ErrInvalidVector", err)
+	}
+	if len(h.Nodes) != 1 || len(cosine.Nodes) != 0 {
+		t.Errorf("invalid input modified the index: %d and %d nodes", len(h.Nodes), len(cosine.Nodes))
+	}
+}
+
 func TestSaveLoad(t *testing.T) {
 	filename := "test_index.hnsw"
 	defer os.Remove(filename)
@@ -244,11 +801,18 @@ func TestSaveLoad(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load() error = %v", err)
 	}
+	checkInbound(t, h2)
 
 	// Compare search results
 	query := Vector{1.1, 1.1}
-	results1 := h1.Search(query, 1)
-	results2 := h2.Search(query, 1)
+	results1, err := h1.Search(query, 1)
+	if err != nil {
+		t.Fatalf("Search() before save error = %v", err)
+	}
+	results2, err := h2.Search(query, 1)
+	if err != nil {
+		t.Fatalf("Search() after load error = %v", err)
+	}
 
 	if len(results1) != len(results2) || results1[0] != results2[0] {
 		t.Errorf("Search results differ after load. Got %v; want %v", results2, results1)
@@ -272,8 +836,8 @@ func TestConcurrentInserts(t *testing.T) {
 	<-done
 	<-done
 
-	results := h.Search(Vector{1.5, 1.5}, 2)
-	if len(results) != 2 {
+	results, err := h.Search(Vector{1.5, 1.5}, 2)
+	if err != nil || len(results) != 2 {
 		t.Error("Concurrent Insert() failed")
 	}
 }
@@ -0,0 +1,76 @@
+// metric.go
+package hnsw
+
+import (
+	"math"
+	"reflect"
+)
+
+// rankMetric is the form of the index metric used inside the index. Its
+// distances order vectors like DistanceFunc but may be cheaper to compute,
+// and are converted back before they reach callers.
+type rankMetric struct {
+	distance DistanceFunc
+	batch    BatchDistanceFunc
+	// squared marks distances that are the square of DistanceFunc
+	squared bool
+}
+
+// trueDistance converts a ranking distance to the value of DistanceFunc
+func (m rankMetric) trueDistance(dist float64) float64 {
+	if m.squared {
+		return math.Sqrt(dist)
+	}
+	return dist
+}
+
+// rankDistance converts a DistanceFunc value, such as a radius, to a ranking distance
+func (m rankMetric) rankDistance(dist float64) float64 {
+	if m.squared {
+		return dist * dist
+	}
+	return dist
+}
+
+// builtinMetrics pairs the built-in metrics with the form used for ranking.
+// Euclidean ranks by its square to skip a square root per evaluation.
+var builtinMetrics = []struct {
+	metric DistanceFunc
+	rank   rankMetric
+}{
+	{Euclidean, rankMetric{EuclideanSquared, BatchEuclideanSquared, true}},
+	{EuclideanSquared, rankMetric{EuclideanSquared, BatchEuclideanSquared, false}},
+	{Cosine, rankMetric{Cosine, BatchCosine, false}},
+	{InnerProduct, rankMetric{InnerProduct, BatchDot, false}},
+}
+
+// metric returns the ranking form of the index metric. A custom batch
+// function is a batch form of DistanceFunc itself, so it disables the
+// squared ranking of Euclidean.
+func (h *HNSW) metric() rankMetric {
+	if h.BatchDistanceFunc != nil {
+		return rankMetric{distance: h.DistanceFunc, batch: h.BatchDistanceFunc}
+	}
+	for _, builtin := range builtinMetrics {
+		if sameFunc(h.DistanceFunc, builtin.metric) {
+			return builtin.rank
+		}
+	}
+
+	distanceFunc := h.DistanceFunc
+	return rankMetric{
+		distance: distanceFunc,
+		batch: func(query Vector, vectors []Vector) []float64 {
+			results := make([]float64, len(vectors))
+			for i, vec := range vectors {
+				results[i] = distanceFunc(vec, query)
+			}
+			return results
+		},
+	}
+}
+
+// sameFunc reports whether two distance functions are the same function
+func sameFunc(a, b DistanceFunc) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
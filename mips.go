@@ -0,0 +1,53 @@
+// mips.go
+package hnsw
+
+import "math"
+
+// MIPSTransform reduces maximum inner product search to Euclidean search.
+// Each stored vector x gains the component sqrt(MaxNorm² - |x|²) and each
+// query q gains a 0, which makes |q' - x'|² = |q|² + MaxNorm² - 2<q, x>. The
+// Euclidean nearest neighbors of a transformed query are then the vectors
+// with the largest inner products, so an index of dimension dim+1 built with
+// Euclidean serves inner product queries with a proper metric.
+type MIPSTransform struct {
+	// MaxNorm must be at least the norm of every stored vector
+	MaxNorm float64
+}
+
+// NewMIPSTransform returns a transform sized for the given vectors
+func NewMIPSTransform(vectors []Vector) *MIPSTransform {
+	var maxNorm float64
+	for _, vec := range vectors {
+		maxNorm = math.Max(maxNorm, norm(vec))
+	}
+	return &MIPSTransform{MaxNorm: maxNorm}
+}
+
+// Item returns the augmented form of a vector to store in the index. Vectors
+// longer than MaxNorm get a 0 component and may rank below their inner product.
+func (t *MIPSTransform) Item(vec Vector) Vector {
+	n := norm(vec)
+	out := make(Vector, len(vec)+1)
+	copy(out, vec)
+	out[len(vec)] = math.Sqrt(math.Max(t.MaxNorm*t.MaxNorm-n*n, 0))
+	return out
+}
+
+// Query returns the augmented form of a query vector
+func (t *MIPSTransform) Query(vec Vector) Vector {
+	out := make(Vector, len(vec)+1)
+	copy(out, vec)
+	return out
+}
+
+// Score recovers the inner product of the original query and a result from
+// the Euclidean distance returned for the transformed query
+func (t *MIPSTransform) Score(query Vector, distance float64) float64 {
+	n := norm(query)
+	return (n*n + t.MaxNorm*t.MaxNorm - distance*distance) / 2
+}
+
+// norm returns the Euclidean length of vec
+func norm(vec Vector) float64 {
+	return math.Sqrt(dot(vec, vec))
+}
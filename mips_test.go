@@ -0,0 +1,98 @@
+// mips_test.go
+package hnsw
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// topInnerProducts returns the IDs of the k vectors with the largest inner product with query
+func topInnerProducts(vectors []Vector, query Vector, k int) []int {
+	ids := make([]int, len(vectors))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return InnerProduct(vectors[ids[i]], query) < InnerProduct(vectors[ids[j]], query)
+	})
+	return ids[:k]
+}
+
+// randomScaledVectors returns vectors in random directions with norms up to 10
+func randomScaledVectors(rng *rand.Rand, n, dim int) []Vector {
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		scale := 1 + rng.Float64()*9
+		vectors[i] = make(Vector, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.NormFloat64() * scale
+		}
+	}
+	return vectors
+}
+
+func TestInnerProductIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(41))
+	vectors := randomScaledVectors(rng, 2000, 3)
+
+	h, err := NewWithOptions(3, WithM(16), WithMetric(InnerProduct), WithSeed(41))
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	for i, v := range vectors {
+		h.Insert(i, v)
+	}
+
+	query := Vector{0.3, -0.8, 0.5}
+	for _, parallel := range []bool{false, true} {
+		results, err := h.SearchWithDistances(query, 10, SearchConfig{UseParallel: parallel, Ef: 100})
+		if err != nil {
+			t.Fatalf("SearchWithDistances() error = %v", err)
+		}
+		ids := make([]int, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		if got := recall(ids, topInnerProducts(vectors, query, 10)); got < 0.9 {
+			t.Errorf("parallel=%v: inner product recall@10 = %.2f; want >= 0.9", parallel, got)
+		}
+	}
+}
+
+func TestMIPSTransform(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	vectors := randomScaledVectors(rng, 2000, 3)
+	transform := NewMIPSTransform(vectors)
+
+	h, err := NewWithOptions(4, WithM(16), WithSeed(42))
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	for i, v := range vectors {
+		item := transform.Item(v)
+		if n := norm(item); math.Abs(n-transform.MaxNorm) > 1e-9 {
+			t.Fatalf("item %d has norm %v; want %v", i, n, transform.MaxNorm)
+		}
+		h.Insert(i, item)
+	}
+
+	query := Vector{-0.4, 0.1, 0.9}
+	results, err := h.SearchWithDistances(transform.Query(query), 10, SearchConfig{Ef: 100})
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+		want := -InnerProduct(vectors[r.ID], query)
+		if got := transform.Score(query, r.Distance); math.Abs(got-want) > 1e-6 {
+			t.Errorf("Score() of result %d = %v; want %v", i, got, want)
+		}
+	}
+	if got := recall(ids, topInnerProducts(vectors, query, 10)); got < 0.9 {
+		t.Errorf("MIPS recall@10 = %.2f; want >= 0.9", got)
+	}
+}
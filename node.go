@@ -14,9 +14,76 @@ type Node struct {
     Vector   Vector
     Levels   []*Level
     MaxLevel int
+    // inbound lists the nodes linking to this one on each level, so that a
+    // delete only visits them. It is guarded by the index write lock.
+    inbound [][]*Node
     sync.RWMutex
 }
 
+// setConnections replaces the links of n on level and keeps the inbound lists
+// of the nodes it gains and loses in sync. The caller must hold the index
+// write lock.
+func (n *Node) setConnections(level int, conns []*Node) {
+    old := n.Levels[level].Connections
+    for _, o := range old {
+        if o != nil && !containsNode(conns, o) {
+            o.removeInbound(n, level)
+        }
+    }
+    for _, c := range conns {
+        if c != nil && !containsNode(old, c) {
+            c.addInbound(n, level)
+        }
+    }
+    n.Levels[level].Connections = conns
+}
+
+func (n *Node) addInbound(from *Node, level int) {
+    for len(n.inbound) <= level {
+        n.inbound = append(n.inbound, nil)
+    }
+    n.inbound[level] = append(n.inbound[level], from)
+}
+
+func (n *Node) removeInbound(from *Node, level int) {
+    if level >= len(n.inbound) {
+        return
+    }
+    links := n.inbound[level]
+    for i, node := range links {
+        if node == from {
+            links[i] = links[len(links)-1]
+            links[len(links)-1] = nil
+            n.inbound[level] = links[:len(links)-1]
+            return
+        }
+    }
+}
+
+// linkedFrom returns the nodes linking to n on any level
+func (n *Node) linkedFrom() []*Node {
+    seen := make(map[*Node]bool)
+    var nodes []*Node
+    for _, links := range n.inbound {
+        for _, node := range links {
+            if !seen[node] {
+                seen[node] = true
+                nodes = append(nodes, node)
+            }
+        }
+    }
+    return nodes
+}
+
+func containsNode(nodes []*Node, node *Node) bool {
+    for _, n := range nodes {
+        if n == node {
+            return true
+        }
+    }
+    return false
+}
+
 // serialLevel is used for serialization
 type serialLevel struct {
     ConnectionIDs []int
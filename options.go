@@ -0,0 +1,156 @@
+// options.go
+package hnsw
+
+import "fmt"
+
+// Option configures an index created by NewWithOptions
+type Option func(*options)
+
+type options struct {
+	m              int
+	mmax           int
+	hasMmax        bool
+	mmax0          int
+	hasMmax0       bool
+	efConstruction int
+	efSearch       int
+	exactThreshold int
+	levelMult      float64
+	seed           int64
+	hasSeed        bool
+	distanceFunc   DistanceFunc
+	batchFunc      BatchDistanceFunc
+	selector       NeighborSelector
+}
+
+// WithM sets the number of neighbors linked to each new node (default 16)
+func WithM(m int) Option {
+	return func(o *options) { o.m = m }
+}
+
+// WithMmax sets the max connections per node on upper layers (default M)
+func WithMmax(mmax int) Option {
+	return func(o *options) {
+		o.mmax = mmax
+		o.hasMmax = true
+	}
+}
+
+// WithMmax0 sets the max connections per node on layer 0 (default 2*M)
+func WithMmax0(mmax0 int) Option {
+	return func(o *options) {
+		o.mmax0 = mmax0
+		o.hasMmax0 = true
+	}
+}
+
+// WithEfConstruction sets the candidate list size used while inserting (default 200)
+func WithEfConstruction(ef int) Option {
+	return func(o *options) { o.efConstruction = ef }
+}
+
+// WithEfSearch sets the default candidate list size used while searching (see SetEf)
+func WithEfSearch(ef int) Option {
+	return func(o *options) { o.efSearch = ef }
+}
+
+// WithExactThreshold makes searches scan every node while the index holds
+// fewer than n live vectors (see SetExactThreshold)
+func WithExactThreshold(n int) Option {
+	return func(o *options) { o.exactThreshold = n }
+}
+
+// WithLevelMultiplier sets the level multiplier mL (default 1/ln(M))
+func WithLevelMultiplier(mL float64) Option {
+	return func(o *options) { o.levelMult = mL }
+}
+
+// WithSeed seeds the random source used to assign node levels
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.hasSeed = true
+	}
+}
+
+// WithMetric sets the distance function (default Euclidean)
+func WithMetric(distanceFunc DistanceFunc) Option {
+	return func(o *options) { o.distanceFunc = distanceFunc }
+}
+
+// WithBatchMetric sets the batch form of the distance function, used by
+// parallel and exact searches (default derived from the metric)
+func WithBatchMetric(batchFunc BatchDistanceFunc) Option {
+	return func(o *options) { o.batchFunc = batchFunc }
+}
+
+// WithNeighborSelector sets the neighbor selection strategy (default HeuristicSelector)
+func WithNeighborSelector(selector NeighborSelector) Option {
+	return func(o *options) { o.selector = selector }
+}
+
+// NewWithOptions creates a new HNSW index for vectors of the given dimension.
+// It returns ErrInvalidConfig if the options don't form a usable index.
+func NewWithOptions(dim int, opts ...Option) (*HNSW, error) {
+	o := options{
+		m:              16,
+		efConstruction: 200,
+		distanceFunc:   Euclidean,
+		selector:       HeuristicSelector{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Connection limits default relative to the final M
+	if !o.hasMmax {
+		o.mmax = o.m
+	}
+	if !o.hasMmax0 {
+		o.mmax0 = 2 * o.m
+	}
+
+	if err := o.validate(dim); err != nil {
+		return nil, err
+	}
+
+	h := New(dim, o.m, o.mmax0, o.efConstruction, o.distanceFunc)
+	h.Mmax = o.mmax
+	h.efSearch = o.efSearch
+	h.exactThreshold = o.exactThreshold
+	h.BatchDistanceFunc = o.batchFunc
+	h.Selector = o.selector
+	if o.levelMult > 0 {
+		h.levelMult = o.levelMult
+	}
+	if o.hasSeed {
+		h.SetSeed(o.seed)
+	}
+	return h, nil
+}
+
+func (o *options) validate(dim int) error {
+	switch {
+	case dim < 1:
+		return fmt.Errorf("%w: dimension %d must be at least 1", ErrInvalidConfig, dim)
+	case o.m < 2:
+		return fmt.Errorf("%w: M %d must be at least 2", ErrInvalidConfig, o.m)
+	case o.mmax < o.m:
+		return fmt.Errorf("%w: Mmax %d must be at least M %d", ErrInvalidConfig, o.mmax, o.m)
+	case o.mmax0 < o.m:
+		return fmt.Errorf("%w: Mmax0 %d must be at least M %d", ErrInvalidConfig, o.mmax0, o.m)
+	case o.efConstruction < 1:
+		return fmt.Errorf("%w: efConstruction %d must be at least 1", ErrInvalidConfig, o.efConstruction)
+	case o.efSearch < 0:
+		return fmt.Errorf("%w: efSearch %d must not be negative", ErrInvalidConfig, o.efSearch)
+	case o.exactThreshold < 0:
+		return fmt.Errorf("%w: exact search threshold %d must not be negative", ErrInvalidConfig, o.exactThreshold)
+	case o.levelMult < 0:
+		return fmt.Errorf("%w: level multiplier %v must not be negative", ErrInvalidConfig, o.levelMult)
+	case o.distanceFunc == nil:
+		return fmt.Errorf("%w: metric must not be nil", ErrInvalidConfig)
+	case o.selector == nil:
+		return fmt.Errorf("%w: neighbor selector must not be nil", ErrInvalidConfig)
+	}
+	return nil
+}
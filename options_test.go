@@ -0,0 +1,101 @@
+// options_test.go
+package hnsw
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	h, err := NewWithOptions(8)
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	if h.Dim != 8 || h.M != 16 || h.Mmax != 16 || h.Mmax0 != 32 || h.EfConstruction != 200 {
+		t.Errorf("NewWithOptions() defaults: M=%d Mmax=%d Mmax0=%d efConstruction=%d",
+			h.M, h.Mmax, h.Mmax0, h.EfConstruction)
+	}
+
+	h, err = NewWithOptions(8,
+		WithM(8),
+		WithMmax(12),
+		WithMmax0(24),
+		WithEfConstruction(64),
+		WithEfSearch(40),
+		WithMetric(Cosine),
+		WithNeighborSelector(SimpleSelector{}),
+		WithSeed(3),
+	)
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	if h.M != 8 || h.Mmax != 12 || h.Mmax0 != 24 || h.EfConstruction != 64 || h.efSearch != 40 {
+		t.Errorf("NewWithOptions() ignored options: M=%d Mmax=%d Mmax0=%d efConstruction=%d efSearch=%d",
+			h.M, h.Mmax, h.Mmax0, h.EfConstruction, h.efSearch)
+	}
+	if _, ok := h.Selector.(SimpleSelector); !ok {
+		t.Errorf("Selector = %T; want SimpleSelector", h.Selector)
+	}
+}
+
+func TestNewWithOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		dim  int
+		opts []Option
+	}{
+		{"Zero dimension", 0, nil},
+		{"M below 2", 8, []Option{WithM(1)}},
+		{"Mmax below M", 8, []Option{WithM(16), WithMmax(8)}},
+		{"Mmax0 below M", 8, []Option{WithM(16), WithMmax0(8)}},
+		{"negative Mmax", 8, []Option{WithMmax(-1)}},
+		{"negative Mmax0", 8, []Option{WithMmax0(-1)}},
+		{"Zero efConstruction", 8, []Option{WithEfConstruction(0)}},
+		{"Negative efSearch", 8, []Option{WithEfSearch(-1)}},
+		{"Negative exact threshold", 8, []Option{WithExactThreshold(-1)}},
+		{"Nil metric", 8, []Option{WithMetric(nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWithOptions(tt.dim, tt.opts...); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("NewWithOptions() error = %v; want ErrInvalidConfig", err)
+			}
+		})
+	}
+}
+
+func TestConnectionLimits(t *testing.T) {
+	h, err := NewWithOptions(2, WithM(4), WithMmax(6), WithMmax0(10), WithEfConstruction(32), WithSeed(1))
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		h.Insert(i, Vector{rng.Float64(), rng.Float64()})
+	}
+
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			if limit := h.maxConnections(level); len(l.Connections) > limit {
+				t.Fatalf("node %d has %d connections on level %d; limit %d", id, len(l.Connections), level, limit)
+			}
+		}
+	}
+
+	filename := "test_options.hnsw"
+	defer os.Remove(filename)
+	if err := h.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(filename, Euclidean)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.M != 4 || loaded.Mmax != 6 || loaded.Mmax0 != 10 {
+		t.Errorf("Load() limits: M=%d Mmax=%d Mmax0=%d", loaded.M, loaded.Mmax, loaded.Mmax0)
+	}
+}
@@ -0,0 +1,243 @@
+// search.go
+package hnsw
+
+import (
+	"container/heap"
+	"fmt"
+	"iter"
+)
+
+// SearchFiltered finds the k nearest neighbors whose IDs pass filter using the
+// default config. The filter is applied during traversal, so non-matching
+// nodes still guide the search but never take a result slot. When few nodes
+// match, the search falls back to a linear scan.
+func (h *HNSW) SearchFiltered(vec Vector, k int, filter func(id int) bool) ([]SearchResult, error) {
+	config := DefaultSearchConfig()
+	config.Filter = filter
+	return h.SearchWithDistances(vec, k, config)
+}
+
+// NeighborsOf finds the k nearest neighbors of the indexed node with the given
+// ID, excluding the node itself. The search starts at the node instead of the
+// global entry point, so no descent through the upper layers is needed. It
+// returns ErrNotFound if the ID is not indexed.
+func (h *HNSW) NeighborsOf(id, k int) ([]SearchResult, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	node, exists := h.Nodes[id]
+	if !exists || h.deletedNodes[id] {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if k <= 0 {
+		return []SearchResult{}, nil
+	}
+
+	config := SearchConfig{}
+	accept := h.acceptFunc(config)
+	opts := &searchOpts{accept: func(n *Node) bool {
+		return n != node && (accept == nil || accept(n))
+	}}
+	return h.searchFrom(node, node.Vector, k, config, opts)
+}
+
+// SearchIter returns an iterator over the live neighbors of vec, closest first,
+// yielding each ID with its distance. The base layer is explored only as far
+// as needed for the next result, so consumers that reject an unknown number of
+// hits can keep pulling instead of searching again with a larger k. Like
+// Search, the order is approximate: a node is yielded once an ef search
+// around it would keep it. The read lock is held only while the iterator
+// advances, so the loop body may call back into the index. An invalid vec
+// yields nothing.
+func (h *HNSW) SearchIter(vec Vector) iter.Seq2[int, float64] {
+	return func(yield func(int, float64) bool) {
+		if h.validateVector(vec) != nil {
+			return
+		}
+
+		it := &neighborIter{
+			vec:        vec,
+			visited:    make(map[int]bool),
+			candidates: &nodeDistMinHeap{},
+			window:     &nodeDistHeap{},
+			overflow:   &nodeDistMinHeap{},
+		}
+		for {
+			h.mutex.RLock()
+			next, ok := it.next(h)
+			var dist float64
+			if ok {
+				dist = h.metric().trueDistance(next.dist)
+			}
+			h.mutex.RUnlock()
+
+			if !ok || !yield(next.node.ID, dist) {
+				return
+			}
+		}
+	}
+}
+
+// neighborIter holds the state of an incremental base layer search. Found
+// nodes that have not been yielded yet are split between window, which holds
+// the ef closest of them, and overflow.
+type neighborIter struct {
+	vec        Vector
+	started    bool
+	visited    map[int]bool
+	candidates *nodeDistMinHeap
+	window     *nodeDistHeap
+	overflow   *nodeDistMinHeap
+}
+
+// next explores the base layer until no candidate is closer than the worst
+// node in the window, as searchLayer would, and pops the closest live node.
+// Nodes are checked when popped, since the index may change between calls.
+// The caller must hold the read lock.
+func (it *neighborIter) next(h *HNSW) (*nodeDist, bool) {
+	distance := h.metric().distance
+	if !it.started {
+		it.started = true
+		if h.EntryPoint == nil {
+			return nil, false
+		}
+		entry := h.greedySearch(h.EntryPoint, it.vec, h.MaxLevel, 1, nil)
+		it.visit(entry, distance)
+	}
+
+	// Look ahead at least a full base layer neighborhood, a smaller window
+	// lets nodes reached late through a detour come out of order
+	ef := max(h.searchEf(1, SearchConfig{}), h.Mmax0)
+	accept := h.acceptFunc(SearchConfig{})
+	for {
+		for it.candidates.Len() > 0 && (it.window.Len() < ef || (*it.candidates)[0].dist < (*it.window)[0].dist) {
+			current := heap.Pop(it.candidates).(*nodeDist)
+
+			current.node.RLock()
+			if len(current.node.Levels) > 0 && current.node.Levels[0] != nil {
+				for _, neighbor := range current.node.Levels[0].Connections {
+					if neighbor != nil && !it.visited[neighbor.ID] {
+						it.visit(neighbor, distance)
+					}
+				}
+			}
+			current.node.RUnlock()
+
+			for it.window.Len() > ef {
+				heap.Push(it.overflow, heap.Pop(it.window))
+			}
+		}
+
+		if it.window.Len() == 0 {
+			return nil, false
+		}
+
+		// The window is a max-heap, so find its closest node by scanning
+		closest := 0
+		for i, nd := range *it.window {
+			if nd.dist < (*it.window)[closest].dist {
+				closest = i
+			}
+		}
+		next := heap.Remove(it.window, closest).(*nodeDist)
+		if it.overflow.Len() > 0 {
+			heap.Push(it.window, heap.Pop(it.overflow))
+		}
+
+		if h.Nodes[next.node.ID] == next.node && (accept == nil || accept(next.node)) {
+			return next, true
+		}
+	}
+}
+
+// visit records a newly found node as both a candidate and a pending result
+func (it *neighborIter) visit(node *Node, distance DistanceFunc) {
+	it.visited[node.ID] = true
+	nd := &nodeDist{node, distance(node.Vector, it.vec)}
+	heap.Push(it.candidates, nd)
+	heap.Push(it.window, nd)
+}
+
+// SearchExact finds the k nearest live neighbors of vec by computing the
+// distance to every node with the batch kernel of the index metric. It
+// is slower than Search but exact, which makes it the ground truth for
+// measuring recall.
+func (h *HNSW) SearchExact(vec Vector, k int) ([]SearchResult, error) {
+	if err := h.validateVector(vec); err != nil {
+		return nil, err
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if k <= 0 {
+		return []SearchResult{}, nil
+	}
+	return h.scan(vec, k, &searchOpts{accept: h.acceptFunc(SearchConfig{})})
+}
+
+// SetExactThreshold makes searches scan every node instead of traversing the
+// graph while the index holds fewer than n live vectors, so small indexes
+// return exact results. A value of 0 disables the fallback.
+func (h *HNSW) SetExactThreshold(n int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.exactThreshold = max(n, 0)
+}
+
+// scanChunkSize is the number of vectors scan passes to the distance kernel at once
+const scanChunkSize = 256
+
+// scan computes the distance to every accepted node and returns the k closest.
+// If the opts context is done first, it returns the closest seen so far along
+// with the context error. The caller must hold the read lock.
+func (h *HNSW) scan(vec Vector, k int, opts *searchOpts) ([]SearchResult, error) {
+	resultSet := &nodeDistHeap{}
+	chunk := make([]*Node, 0, scanChunkSize)
+	flush := func() {
+		opts.countDistances(len(chunk))
+		for i, dist := range h.distances(vec, chunk) {
+			if resultSet.Len() < k {
+				heap.Push(resultSet, &nodeDist{chunk[i], dist})
+			} else if dist < (*resultSet)[0].dist {
+				(*resultSet)[0] = &nodeDist{chunk[i], dist}
+				heap.Fix(resultSet, 0)
+			}
+		}
+		chunk = chunk[:0]
+	}
+
+	for _, node := range h.Nodes {
+		if !opts.accepts(node) {
+			continue
+		}
+		chunk = append(chunk, node)
+		if len(chunk) == scanChunkSize {
+			flush()
+			if opts.err() != nil {
+				break
+			}
+		}
+	}
+	if opts.err() == nil {
+		flush()
+	}
+
+	metric := h.metric()
+	results := make([]SearchResult, resultSet.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		nd := heap.Pop(resultSet).(*nodeDist)
+		results[i] = SearchResult{ID: nd.node.ID, Distance: metric.trueDistance(nd.dist)}
+	}
+	return results, opts.err()
+}
+
+// distances returns the ranking distance from vec to each node using the
+// batch kernel of the index metric
+func (h *HNSW) distances(vec Vector, nodes []*Node) []float64 {
+	vectors := make([]Vector, len(nodes))
+	for i, node := range nodes {
+		vectors[i] = node.Vector
+	}
+	return h.metric().batch(vec, vectors)
+}
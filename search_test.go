@@ -0,0 +1,567 @@
+// search_test.go
+package hnsw
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func buildRandomIndex(t *testing.T, n int, seed int64) (*HNSW, []Vector) {
+	t.Helper()
+	h, err := NewWithOptions(2, WithM(8), WithEfConstruction(64), WithSeed(seed))
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64(), rng.Float64()}
+		if err := h.Insert(i, vectors[i]); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+	return h, vectors
+}
+
+// exactFiltered returns the IDs of the k vectors closest to query that pass filter
+func exactFiltered(vectors []Vector, query Vector, k int, filter func(int) bool) []int {
+	var ids []int
+	for i := range vectors {
+		if filter(i) {
+			ids = append(ids, i)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return Euclidean(vectors[ids[i]], query) < Euclidean(vectors[ids[j]], query)
+	})
+	return ids[:min(k, len(ids))]
+}
+
+func TestSearchFiltered(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 21)
+	even := func(id int) bool { return id%2 == 0 }
+	query := Vector{0.5, 0.5}
+
+	results, err := h.SearchFiltered(query, 10, even)
+	if err != nil {
+		t.Fatalf("SearchFiltered() error = %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+
+	ids := make([]int, len(results))
+	for i, r := range results {
+		if !even(r.ID) {
+			t.Errorf("result %d has ID %d rejected by the filter", i, r.ID)
+		}
+		ids[i] = r.ID
+	}
+	if got := recall(ids, exactFiltered(vectors, query, 10, even)); got < 0.9 {
+		t.Errorf("filtered recall@10 = %.2f; want >= 0.9", got)
+	}
+}
+
+func TestSearchFilteredSelective(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 22)
+	allowed := map[int]bool{17: true, 1001: true, 1999: true}
+	filter := func(id int) bool { return allowed[id] }
+	query := Vector{0.1, 0.9}
+
+	for _, parallel := range []bool{false, true} {
+		config := SearchConfig{UseParallel: parallel, Filter: filter}
+		results, stats, err := h.SearchWithStats(query, 10, config)
+		if err != nil {
+			t.Fatalf("parallel=%v: SearchWithStats() error = %v", parallel, err)
+		}
+
+		// The traversal gives up early, so the search costs about one scan
+		if limit := len(vectors) * 3 / 2; stats.DistanceEvals > limit {
+			t.Errorf("parallel=%v: %d distance evaluations; want at most %d", parallel, stats.DistanceEvals, limit)
+		}
+
+		want := exactFiltered(vectors, query, 10, filter)
+		if len(results) != len(want) {
+			t.Fatalf("parallel=%v: got %d results, want %d", parallel, len(results), len(want))
+		}
+		for i := range want {
+			if results[i].ID != want[i] {
+				t.Errorf("parallel=%v: result %d = %d; want %d", parallel, i, results[i].ID, want[i])
+			}
+		}
+	}
+}
+
+func TestSearchFilteredTombstones(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 500, 23)
+	h.SetDeleteMode(DeleteTombstone)
+
+	// Tombstone the 20 nodes closest to the query
+	query := Vector{0.5, 0.5}
+	all := func(int) bool { return true }
+	for _, id := range exactFiltered(vectors, query, 20, all) {
+		h.Delete(id)
+	}
+
+	results, err := h.SearchWithDistances(query, 10, SearchConfig{})
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if h.deletedNodes[r.ID] {
+			t.Errorf("result contains tombstoned node %d", r.ID)
+		}
+	}
+}
+
+func TestSearchRadius(t *testing.T) {
+	h := New(2, 16, 32, 100, Euclidean)
+	for i := 0; i < 100; i++ {
+		h.Insert(i, Vector{float64(i / 10), float64(i % 10)})
+	}
+
+	tests := []struct {
+		name   string
+		query  Vector
+		radius float64
+		limit  int
+		want   int
+	}{
+		{"Exact point", Vector{3, 3}, 0, 0, 1},
+		{"Four corners", Vector{4.5, 4.5}, 1, 0, 4},
+		{"Two rings", Vector{4.5, 4.5}, 1.6, 0, 12},
+		{"Limited", Vector{4.5, 4.5}, 1.6, 5, 5},
+		{"Nothing in range", Vector{50, 50}, 1, 0, 0},
+		{"Negative radius", Vector{3, 3}, -1, 0, 0},
+		{"NaN radius", Vector{3, 3}, math.NaN(), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := h.SearchRadius(tt.query, tt.radius, tt.limit)
+			if err != nil {
+				t.Fatalf("SearchRadius() error = %v", err)
+			}
+			if len(results) != tt.want {
+				t.Fatalf("got %d results, want %d", len(results), tt.want)
+			}
+			for i, r := range results {
+				if r.Distance > tt.radius {
+					t.Errorf("result %d at distance %v outside radius %v", i, r.Distance, tt.radius)
+				}
+				if i > 0 && r.Distance < results[i-1].Distance {
+					t.Errorf("results not sorted at %d", i)
+				}
+			}
+		})
+	}
+}
+
+// TestSearchRadiusInnerProduct verifies negative radii select vectors by a
+// minimum dot product
+func TestSearchRadiusInnerProduct(t *testing.T) {
+	h := New(2, 16, 32, 100, InnerProduct)
+	h.SetSeed(25)
+	rng := rand.New(rand.NewSource(25))
+
+	// Norms within a narrow band keep the inner product graph navigable;
+	// widely spread norms need MIPSTransform for good recall
+	vectors := make([]Vector, 500)
+	for i := range vectors {
+		angle, norm := rng.Float64()*2*math.Pi, 0.5+rng.Float64()
+		vectors[i] = Vector{norm * math.Cos(angle), norm * math.Sin(angle)}
+		h.Insert(i, vectors[i])
+	}
+	query := Vector{0.6, 0.8}
+
+	// dot >= 0.5 is an inner product distance of at most -0.5
+	want := 0
+	for _, v := range vectors {
+		if InnerProduct(v, query) <= -0.5 {
+			want++
+		}
+	}
+
+	results, err := h.SearchRadius(query, -0.5, 0)
+	if err != nil {
+		t.Fatalf("SearchRadius() error = %v", err)
+	}
+	if want == 0 || float64(len(results)) < 0.95*float64(want) {
+		t.Errorf("got %d results, want about %d", len(results), want)
+	}
+	for i, r := range results {
+		if r.Distance > -0.5 {
+			t.Errorf("result %d at distance %v outside radius -0.5", i, r.Distance)
+		}
+	}
+}
+
+func TestSearchRadiusRecall(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 3000, 24)
+	query := Vector{0.3, 0.6}
+	radius := 0.08
+
+	want := 0
+	for _, v := range vectors {
+		if Euclidean(v, query) <= radius {
+			want++
+		}
+	}
+
+	results, err := h.SearchRadius(query, radius, 0)
+	if err != nil {
+		t.Fatalf("SearchRadius() error = %v", err)
+	}
+	if want == 0 || float64(len(results)) < 0.95*float64(want) {
+		t.Errorf("found %d of %d vectors within radius", len(results), want)
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 1000, 25)
+	query := Vector{0.5, 0.5}
+
+	results, err := h.SearchContext(context.Background(), query, 10, SearchConfig{})
+	if err != nil {
+		t.Fatalf("SearchContext() error = %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := h.SearchContext(ctx, query, 10, SearchConfig{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SearchContext() with cancelled context error = %v; want %v", err, context.Canceled)
+	}
+
+	// A slow filter makes the search outlive its deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow := func(id int) bool {
+		time.Sleep(time.Millisecond)
+		return true
+	}
+
+	start := time.Now()
+	results, err = h.SearchContext(ctx, query, 10, SearchConfig{Ef: len(vectors), Filter: slow})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SearchContext() error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SearchContext() returned %v after its deadline", elapsed)
+	}
+	if len(results) == 0 || len(results) > 10 {
+		t.Errorf("got %d partial results, want 1 to 10", len(results))
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Distance < results[i-1].Distance {
+			t.Errorf("partial results not sorted at %d", i)
+		}
+	}
+}
+
+func TestInsertContext(t *testing.T) {
+	h, _ := buildRandomIndex(t, 200, 26)
+	before := graphSignature(h)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := h.InsertContext(ctx, 500, Vector{0.5, 0.5}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("InsertContext() error = %v; want %v", err, context.Canceled)
+	}
+	if _, exists := h.Nodes[500]; exists || !reflect.DeepEqual(graphSignature(h), before) {
+		t.Fatal("cancelled InsertContext() modified the index")
+	}
+
+	if err := h.InsertContext(context.Background(), 500, Vector{0.5, 0.5}); err != nil {
+		t.Fatalf("InsertContext() error = %v", err)
+	}
+	if results, _ := h.Search(Vector{0.5, 0.5}, 1); len(results) != 1 || results[0] != 500 {
+		t.Errorf("Search() = %v; want [500]", results)
+	}
+}
+
+func TestNeighborsOf(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 27)
+
+	for _, id := range []int{0, 512, 1999} {
+		results, err := h.NeighborsOf(id, 10)
+		if err != nil {
+			t.Fatalf("NeighborsOf(%d) error = %v", id, err)
+		}
+		if len(results) != 10 {
+			t.Fatalf("NeighborsOf(%d) returned %d results, want 10", id, len(results))
+		}
+
+		ids := make([]int, len(results))
+		for i, r := range results {
+			if r.ID == id {
+				t.Errorf("NeighborsOf(%d) returned the node itself", id)
+			}
+			ids[i] = r.ID
+		}
+		others := func(other int) bool { return other != id }
+		if got := recall(ids, exactFiltered(vectors, vectors[id], 10, others)); got < 0.9 {
+			t.Errorf("NeighborsOf(%d) recall@10 = %.2f; want >= 0.9", id, got)
+		}
+	}
+
+	if _, err := h.NeighborsOf(5000, 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("NeighborsOf() of unknown ID error = %v; want %v", err, ErrNotFound)
+	}
+	h.SetDeleteMode(DeleteTombstone)
+	h.Delete(7)
+	if _, err := h.NeighborsOf(7, 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("NeighborsOf() of tombstoned ID error = %v; want %v", err, ErrNotFound)
+	}
+}
+
+func TestSearchExclude(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 28)
+	query := Vector{0.4, 0.7}
+	all := func(int) bool { return true }
+
+	exclude := make(map[int]bool)
+	for _, id := range exactFiltered(vectors, query, 5, all) {
+		exclude[id] = true
+	}
+
+	results, err := h.SearchWithDistances(query, 10, SearchConfig{Exclude: exclude})
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+
+	ids := make([]int, len(results))
+	for i, r := range results {
+		if exclude[r.ID] {
+			t.Errorf("result %d has excluded ID %d", i, r.ID)
+		}
+		ids[i] = r.ID
+	}
+	allowed := func(id int) bool { return !exclude[id] }
+	if got := recall(ids, exactFiltered(vectors, query, 10, allowed)); got < 0.9 {
+		t.Errorf("recall@10 with exclusions = %.2f; want >= 0.9", got)
+	}
+}
+
+func TestSearchOffset(t *testing.T) {
+	h, _ := buildRandomIndex(t, 2000, 29)
+	query := Vector{0.2, 0.3}
+
+	want, err := h.SearchWithDistances(query, 30, SearchConfig{Ef: 100})
+	if err != nil {
+		t.Fatalf("SearchWithDistances() error = %v", err)
+	}
+
+	var pages []SearchResult
+	for offset := 0; offset < 30; offset += 10 {
+		page, err := h.SearchWithDistances(query, 10, SearchConfig{Ef: 100, Offset: offset})
+		if err != nil {
+			t.Fatalf("offset %d: SearchWithDistances() error = %v", offset, err)
+		}
+		if len(page) != 10 {
+			t.Fatalf("offset %d: got %d results, want 10", offset, len(page))
+		}
+		pages = append(pages, page...)
+	}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("concatenated pages = %v; want %v", pages, want)
+	}
+
+	past, err := h.SearchWithDistances(query, 10, SearchConfig{Offset: 5000})
+	if err != nil || len(past) != 0 {
+		t.Errorf("SearchWithDistances() past the end = %v, %v; want no results", past, err)
+	}
+}
+
+func TestSearchIter(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 2000, 30)
+	query := Vector{0.6, 0.4}
+
+	var ids []int
+	var last float64
+	for id, dist := range h.SearchIter(query) {
+		if dist < last {
+			t.Errorf("distance %v after %v is out of order", dist, last)
+		}
+		last = dist
+		ids = append(ids, id)
+		if len(ids) == 50 {
+			break
+		}
+	}
+	if len(ids) != 50 {
+		t.Fatalf("got %d results, want 50", len(ids))
+	}
+
+	all := func(int) bool { return true }
+	if got := recall(ids, exactFiltered(vectors, query, 50, all)); got < 0.9 {
+		t.Errorf("recall@50 = %.2f; want >= 0.9", got)
+	}
+}
+
+func TestSearchIterExhaustive(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 300, 31)
+	h.SetDeleteMode(DeleteTombstone)
+	for id := 0; id < len(vectors); id += 10 {
+		h.Delete(id)
+	}
+
+	seen := make(map[int]bool)
+	for id := range h.SearchIter(Vector{0.5, 0.5}) {
+		if seen[id] {
+			t.Fatalf("ID %d yielded twice", id)
+		}
+		if id%10 == 0 {
+			t.Errorf("tombstoned ID %d yielded", id)
+		}
+		seen[id] = true
+
+		// The loop body may modify the index
+		if id == 1 {
+			h.Delete(1)
+		}
+	}
+	if want := len(vectors) - len(vectors)/10; len(seen) != want {
+		t.Errorf("iterator yielded %d IDs, want %d", len(seen), want)
+	}
+
+	for range h.SearchIter(Vector{1, 2, 3}) {
+		t.Fatal("iterator over an invalid vector yielded a result")
+	}
+}
+
+func TestSearchExact(t *testing.T) {
+	// A dimension that isn't a multiple of 4 covers the batch kernel's tail
+	const dim = 10
+	h, err := NewWithOptions(dim, WithM(8), WithEfConstruction(32), WithSeed(32))
+	if err != nil {
+		t.Fatalf("NewWithOptions() error = %v", err)
+	}
+	h.SetDeleteMode(DeleteTombstone)
+
+	rng := rand.New(rand.NewSource(32))
+	vectors := make([]Vector, 1000)
+	for i := range vectors {
+		vectors[i] = make(Vector, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float64()
+		}
+		h.Insert(i, vectors[i])
+	}
+	for id := 0; id < len(vectors); id += 7 {
+		h.Delete(id)
+	}
+
+	live := func(id int) bool { return id%7 != 0 }
+	for q := 0; q < 10; q++ {
+		query := make(Vector, dim)
+		for j := range query {
+			query[j] = rng.Float64()
+		}
+
+		results, err := h.SearchExact(query, 20)
+		if err != nil {
+			t.Fatalf("SearchExact() error = %v", err)
+		}
+		want := exactFiltered(vectors, query, 20, live)
+		if len(results) != len(want) {
+			t.Fatalf("got %d results, want %d", len(results), len(want))
+		}
+		for i, r := range results {
+			if r.ID != want[i] {
+				t.Errorf("query %d: result %d = %d; want %d", q, i, r.ID, want[i])
+			}
+			if d := Euclidean(vectors[r.ID], query); math.Abs(r.Distance-d) > 1e-9 {
+				t.Errorf("query %d: result %d distance = %v; want %v", q, i, r.Distance, d)
+			}
+		}
+	}
+}
+
+func TestExactThreshold(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 500, 33)
+	config := SearchConfig{Ef: 1}
+	all := func(int) bool { return true }
+
+	h.SetExactThreshold(len(vectors) + 1)
+	rng := rand.New(rand.NewSource(33))
+	for q := 0; q < 20; q++ {
+		query := Vector{rng.Float64(), rng.Float64()}
+		results, err := h.SearchWithDistances(query, 10, config)
+		if err != nil {
+			t.Fatalf("SearchWithDistances() error = %v", err)
+		}
+		for i, want := range exactFiltered(vectors, query, 10, all) {
+			if results[i].ID != want {
+				t.Fatalf("query %d: result %d = %d; want %d", q, i, results[i].ID, want)
+			}
+		}
+	}
+}
+
+func TestParallelSearchMetric(t *testing.T) {
+	rng := rand.New(rand.NewSource(40))
+	vectors := make([]Vector, 1000)
+	for i := range vectors {
+		// Varying norms make Euclidean and cosine neighbors differ
+		scale := 1 + rng.Float64()*9
+		vectors[i] = Vector{rng.Float64() * scale, rng.Float64() * scale, rng.Float64() * scale}
+	}
+	query := Vector{0.2, 0.9, 0.4}
+
+	var batchCalls int
+	countingCosine := func(query Vector, vectors []Vector) []float64 {
+		batchCalls++
+		return BatchCosine(query, vectors)
+	}
+
+	for _, batch := range []BatchDistanceFunc{nil, countingCosine} {
+		h, err := NewWithOptions(3, WithM(8), WithMetric(Cosine), WithBatchMetric(batch), WithSeed(40))
+		if err != nil {
+			t.Fatalf("NewWithOptions() error = %v", err)
+		}
+		for i, v := range vectors {
+			h.Insert(i, v)
+		}
+
+		results, err := h.SearchWithDistances(query, 10, SearchConfig{UseParallel: true})
+		if err != nil {
+			t.Fatalf("SearchWithDistances() error = %v", err)
+		}
+		exact, _ := h.SearchExact(query, 10)
+
+		ids := make([]int, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+			if want := Cosine(vectors[r.ID], query); math.Abs(r.Distance-want) > 1e-10 {
+				t.Errorf("result %d distance = %v; want cosine distance %v", i, r.Distance, want)
+			}
+		}
+		want := make([]int, len(exact))
+		for i, r := range exact {
+			want[i] = r.ID
+		}
+		if got := recall(ids, want); got < 0.9 {
+			t.Errorf("parallel cosine recall@10 = %.2f; want >= 0.9", got)
+		}
+	}
+
+	if batchCalls == 0 {
+		t.Error("custom batch distance function was never called")
+	}
+}
@@ -0,0 +1,122 @@
+// select.go
+package hnsw
+
+import "sort"
+
+// NeighborSelector chooses which candidates a node keeps as neighbors on a layer.
+// Implementations must return at most m nodes and never return base itself.
+// The distance function ranks vectors like the index metric but may differ in
+// value, e.g. squared Euclidean for Euclidean indexes.
+type NeighborSelector interface {
+	SelectNeighbors(base *Node, candidates []*Node, m, level int, distance DistanceFunc) []*Node
+}
+
+// SimpleSelector keeps the m candidates closest to the base node (paper Algorithm 3)
+type SimpleSelector struct{}
+
+// SelectNeighbors returns the m closest candidates
+func (SimpleSelector) SelectNeighbors(base *Node, candidates []*Node, m, level int, distance DistanceFunc) []*Node {
+	sorted := sortByDistance(base, candidates, distance)
+	count := min(m, len(sorted))
+	selected := make([]*Node, count)
+	for i := 0; i < count; i++ {
+		selected[i] = sorted[i].node
+	}
+	return selected
+}
+
+// HeuristicSelector implements the diversity heuristic from the HNSW paper
+// (Algorithm 4). A candidate is only kept if it is closer to the base node than
+// to any neighbor already selected, which preserves links between clusters.
+type HeuristicSelector struct {
+	// ExtendCandidates adds the neighbors of every candidate to the candidate set
+	ExtendCandidates bool
+	// KeepPrunedConnections fills free slots with the closest discarded candidates
+	KeepPrunedConnections bool
+}
+
+// SelectNeighbors returns up to m candidates chosen by the heuristic
+func (s HeuristicSelector) SelectNeighbors(base *Node, candidates []*Node, m, level int, distance DistanceFunc) []*Node {
+	if s.ExtendCandidates {
+		candidates = extendCandidates(base, candidates, level)
+	}
+
+	selected := make([]*Node, 0, m)
+	var discarded []*Node
+
+	for _, c := range sortByDistance(base, candidates, distance) {
+		if len(selected) >= m {
+			break
+		}
+
+		keep := true
+		for _, r := range selected {
+			if distance(c.node.Vector, r.Vector) < c.dist {
+				keep = false
+				break
+			}
+		}
+
+		if keep {
+			selected = append(selected, c.node)
+		} else if s.KeepPrunedConnections {
+			discarded = append(discarded, c.node)
+		}
+	}
+
+	// Discarded candidates are already ordered by distance
+	for i := 0; i < len(discarded) && len(selected) < m; i++ {
+		selected = append(selected, discarded[i])
+	}
+
+	return selected
+}
+
+// extendCandidates adds the neighbors of each candidate on the given level
+func extendCandidates(base *Node, candidates []*Node, level int) []*Node {
+	seen := make(map[*Node]bool, len(candidates))
+	extended := make([]*Node, 0, len(candidates))
+	for _, c := range candidates {
+		if c != nil && !seen[c] {
+			seen[c] = true
+			extended = append(extended, c)
+		}
+	}
+
+	for _, c := range candidates {
+		if c == nil || c == base {
+			continue
+		}
+		c.RLock()
+		if level < len(c.Levels) && c.Levels[level] != nil {
+			for _, adj := range c.Levels[level].Connections {
+				if adj != nil && adj != base && !seen[adj] {
+					seen[adj] = true
+					extended = append(extended, adj)
+				}
+			}
+		}
+		c.RUnlock()
+	}
+
+	return extended
+}
+
+// sortByDistance returns the unique candidates other than base ordered by
+// their distance to base
+func sortByDistance(base *Node, candidates []*Node, distance DistanceFunc) []nodeDist {
+	seen := make(map[*Node]bool, len(candidates))
+	sorted := make([]nodeDist, 0, len(candidates))
+	for _, c := range candidates {
+		if c == nil || c == base || seen[c] {
+			continue
+		}
+		seen[c] = true
+		sorted = append(sorted, nodeDist{c, distance(base.Vector, c.Vector)})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].dist < sorted[j].dist
+	})
+	return sorted
+}
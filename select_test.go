@@ -0,0 +1,96 @@
+// select_test.go
+package hnsw
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func selectionFixture() (*Node, []*Node) {
+	base := &Node{ID: 0, Vector: Vector{0, 0}}
+	candidates := []*Node{
+		{ID: 1, Vector: Vector{1, 0}},
+		{ID: 2, Vector: Vector{1.1, 0}},
+		{ID: 3, Vector: Vector{1.2, 0}},
+		{ID: 4, Vector: Vector{0, 2}},
+	}
+	return base, candidates
+}
+
+func selectedIDs(nodes []*Node) []int {
+	ids := make([]int, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestNeighborSelectors(t *testing.T) {
+	base, candidates := selectionFixture()
+
+	tests := []struct {
+		name     string
+		selector NeighborSelector
+		m        int
+		want     []int
+	}{
+		{"Simple keeps closest", SimpleSelector{}, 2, []int{1, 2}},
+		{"Heuristic keeps diverse", HeuristicSelector{}, 2, []int{1, 4}},
+		{"Heuristic without pruned", HeuristicSelector{}, 3, []int{1, 4}},
+		{"Heuristic keeps pruned", HeuristicSelector{KeepPrunedConnections: true}, 3, []int{1, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectedIDs(tt.selector.SelectNeighbors(base, candidates, tt.m, 0, Euclidean))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHeuristicExtendCandidates(t *testing.T) {
+	base, candidates := selectionFixture()
+	far := &Node{ID: 5, Vector: Vector{-1, 0}}
+	candidates[0].Levels = []*Level{{Connections: []*Node{base, far}}}
+
+	selector := HeuristicSelector{ExtendCandidates: true}
+	got := selectedIDs(selector.SelectNeighbors(base, candidates[:1], 4, 0, Euclidean))
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 5 {
+		t.Errorf("got %v, want [1 5]", got)
+	}
+}
+
+func TestClusteredRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := New(3, 4, 8, 100, Euclidean)
+
+	// Tight, well separated clusters are where plain distance pruning fails
+	centers := []Vector{{0, 0, 0}, {100, 0, 0}, {0, 100, 0}, {0, 0, 100}}
+	vectors := make([]Vector, 0, 400)
+	for i := 0; i < 400; i++ {
+		c := centers[i%len(centers)]
+		vectors = append(vectors, Vector{c[0] + rng.Float64(), c[1] + rng.Float64(), c[2] + rng.Float64()})
+		h.Insert(i, vectors[i])
+	}
+
+	config := SearchConfig{UseParallel: false}
+	hits := 0
+	for i, c := range centers {
+		results := search(t, h, c, 1, config)
+		if len(results) == 1 && results[0]%len(centers) == i {
+			hits++
+		}
+	}
+
+	if hits != len(centers) {
+		t.Errorf("found %d of %d clusters", hits, len(centers))
+	}
+}
@@ -0,0 +1,73 @@
+// stats.go
+package hnsw
+
+import (
+	"context"
+	"time"
+)
+
+// SearchStats describes the work done by a single search
+type SearchStats struct {
+	// DistanceEvals counts every distance computed against the query
+	DistanceEvals int
+	// NodesVisited counts the nodes reached through graph edges. Each visit
+	// costs a distance evaluation; linear scans evaluate without visiting.
+	NodesVisited int
+	// Hops counts the nodes whose neighbor lists were expanded on each layer,
+	// indexed by level
+	Hops []int
+	// CandidatesPruned counts nodes dropped without being expanded because
+	// they could not improve the results
+	CandidatesPruned int
+	// Elapsed is the wall-clock duration of the search
+	Elapsed time.Duration
+}
+
+// SearchWithStats is SearchWithDistances that also reports the work done by
+// the search, for tuning M and ef against real queries
+func (h *HNSW) SearchWithStats(vec Vector, k int, config SearchConfig) ([]SearchResult, SearchStats, error) {
+	var stats SearchStats
+	if err := h.validateVector(vec); err != nil {
+		return nil, stats, err
+	}
+
+	start := time.Now()
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	results, err := h.search(context.Background(), vec, k, config, &stats)
+	stats.Elapsed = time.Since(start)
+	return results, stats, err
+}
+
+// countVisit records n nodes reached through graph edges
+func (o *searchOpts) countVisit(n int) {
+	if o != nil && o.stats != nil {
+		o.stats.NodesVisited += n
+		o.stats.DistanceEvals += n
+	}
+}
+
+// countDistances records n distances computed outside the graph traversal
+func (o *searchOpts) countDistances(n int) {
+	if o != nil && o.stats != nil {
+		o.stats.DistanceEvals += n
+	}
+}
+
+// countHop records the expansion of a node on the given level
+func (o *searchOpts) countHop(level int) {
+	if o != nil && o.stats != nil {
+		for len(o.stats.Hops) <= level {
+			o.stats.Hops = append(o.stats.Hops, 0)
+		}
+		o.stats.Hops[level]++
+	}
+}
+
+// countPruned records n candidates dropped without expansion
+func (o *searchOpts) countPruned(n int) {
+	if o != nil && o.stats != nil {
+		o.stats.CandidatesPruned += n
+	}
+}
@@ -0,0 +1,63 @@
+// stats_test.go
+package hnsw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchWithStats(t *testing.T) {
+	h, _ := buildRandomIndex(t, 2000, 34)
+	query := Vector{0.3, 0.3}
+
+	for _, parallel := range []bool{false, true} {
+		config := SearchConfig{UseParallel: parallel, Ef: 32}
+		results, stats, err := h.SearchWithStats(query, 10, config)
+		if err != nil {
+			t.Fatalf("parallel=%v: SearchWithStats() error = %v", parallel, err)
+		}
+
+		want, _ := h.SearchWithDistances(query, 10, config)
+		if !reflect.DeepEqual(results, want) {
+			t.Errorf("parallel=%v: results = %v; want %v", parallel, results, want)
+		}
+
+		if stats.NodesVisited == 0 || stats.DistanceEvals < stats.NodesVisited {
+			t.Errorf("parallel=%v: %d nodes visited with %d distance evaluations", parallel, stats.NodesVisited, stats.DistanceEvals)
+		}
+		if len(stats.Hops) != h.MaxLevel+1 {
+			t.Fatalf("parallel=%v: got hops for %d layers, want %d", parallel, len(stats.Hops), h.MaxLevel+1)
+		}
+		for level, hops := range stats.Hops {
+			if hops == 0 {
+				t.Errorf("parallel=%v: no hops on level %d", parallel, level)
+			}
+		}
+		if stats.CandidatesPruned == 0 {
+			t.Errorf("parallel=%v: no candidates pruned", parallel)
+		}
+		if stats.Elapsed <= 0 {
+			t.Errorf("parallel=%v: Elapsed = %v", parallel, stats.Elapsed)
+		}
+	}
+
+	// A wider search does more work
+	_, narrow, _ := h.SearchWithStats(query, 10, SearchConfig{Ef: 10})
+	_, wide, _ := h.SearchWithStats(query, 10, SearchConfig{Ef: 200})
+	if wide.DistanceEvals <= narrow.DistanceEvals {
+		t.Errorf("ef=200 used %d distance evaluations, ef=10 used %d", wide.DistanceEvals, narrow.DistanceEvals)
+	}
+}
+
+func TestSearchWithStatsExact(t *testing.T) {
+	h, vectors := buildRandomIndex(t, 300, 35)
+	h.SetExactThreshold(len(vectors) + 1)
+
+	_, stats, err := h.SearchWithStats(Vector{0.5, 0.5}, 10, SearchConfig{})
+	if err != nil {
+		t.Fatalf("SearchWithStats() error = %v", err)
+	}
+	if stats.DistanceEvals-stats.NodesVisited != len(vectors) {
+		t.Errorf("scan computed %d distances, want %d", stats.DistanceEvals-stats.NodesVisited, len(vectors))
+	}
+}
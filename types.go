@@ -6,3 +6,6 @@ type Vector []float64
 
 // DistanceFunc defines a function that computes distance between two vectors
 type DistanceFunc func(Vector, Vector) float64
+
+// BatchDistanceFunc computes the distances from query to each of vectors
+type BatchDistanceFunc func(query Vector, vectors []Vector) []float64
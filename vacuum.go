@@ -0,0 +1,68 @@
+// vacuum.go
+package hnsw
+
+// Vacuum physically removes tombstoned nodes and relinks their neighborhoods.
+// It returns the number of nodes reclaimed.
+func (h *HNSW) Vacuum() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.vacuum()
+}
+
+// vacuum is Vacuum for callers already holding the write lock
+func (h *HNSW) vacuum() int {
+	if len(h.deletedNodes) == 0 {
+		return 0
+	}
+
+	removed := make(map[*Node]bool, len(h.deletedNodes))
+	for id := range h.deletedNodes {
+		if node, exists := h.Nodes[id]; exists {
+			removed[node] = true
+			delete(h.Nodes, id)
+		}
+		delete(h.deletedNodes, id)
+	}
+
+	h.removeNodes(removed)
+	return len(removed)
+}
+
+// Tombstones returns the number of deleted nodes waiting to be vacuumed
+func (h *HNSW) Tombstones() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.deletedNodes)
+}
+
+// SetAutoVacuum runs Vacuum in the background whenever tombstones make up more
+// than ratio of the indexed nodes. A ratio <= 0 disables automatic vacuuming.
+func (h *HNSW) SetAutoVacuum(ratio float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.vacuumRatio = ratio
+	h.maybeAutoVacuum()
+}
+
+// maybeAutoVacuum starts a background vacuum once the tombstone ratio passes
+// the configured threshold. The caller must hold the write lock.
+func (h *HNSW) maybeAutoVacuum() {
+	if h.vacuumRatio <= 0 || len(h.Nodes) == 0 {
+		return
+	}
+	if float64(len(h.deletedNodes)) <= h.vacuumRatio*float64(len(h.Nodes)) {
+		return
+	}
+	if !h.vacuuming.CompareAndSwap(false, true) {
+		return
+	}
+
+	// The flag is cleared under the same lock as the vacuum, so a Delete
+	// that skips starting a run always sees its tombstones reclaimed
+	go func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.vacuum()
+		h.vacuuming.Store(false)
+	}()
+}
@@ -0,0 +1,149 @@
+// vacuum_test.go
+package hnsw
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+)
+
+func buildTombstoneIndex(n int) (*HNSW, []Vector) {
+	h := New(2, 8, 16, 64, Euclidean)
+	h.SetSeed(9)
+	h.SetDeleteMode(DeleteTombstone)
+	rng := rand.New(rand.NewSource(9))
+
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		vectors[i] = Vector{rng.Float64() * 10, rng.Float64() * 10}
+		h.Insert(i, vectors[i])
+	}
+	return h, vectors
+}
+
+func TestTombstoneDelete(t *testing.T) {
+	h, vectors := buildTombstoneIndex(200)
+	config := SearchConfig{UseParallel: false, Ef: 64}
+
+	target := search(t, h, vectors[42], 1, config)
+	if len(target) != 1 || target[0] != 42 {
+		t.Fatalf("Search() = %v; want [42]", target)
+	}
+
+	h.Delete(42)
+	if _, exists := h.Nodes[42]; !exists {
+		t.Fatal("tombstoned node removed from graph before Vacuum")
+	}
+	if got := h.Tombstones(); got != 1 {
+		t.Errorf("Tombstones() = %d; want 1", got)
+	}
+	for _, id := range search(t, h, vectors[42], 5, config) {
+		if id == 42 {
+			t.Fatal("Search() returned tombstoned node")
+		}
+	}
+
+	// Reusing a tombstoned ID replaces the old node
+	h.Insert(42, Vector{20, 20})
+	if h.Tombstones() != 0 {
+		t.Errorf("Tombstones() = %d after re-insert; want 0", h.Tombstones())
+	}
+	if results := search(t, h, Vector{20, 20}, 1, config); len(results) != 1 || results[0] != 42 {
+		t.Errorf("Search() = %v; want [42]", results)
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	h, vectors := buildTombstoneIndex(500)
+
+	for i := 0; i < len(vectors); i += 3 {
+		h.Delete(i)
+	}
+	want := h.Tombstones()
+
+	if got := h.Vacuum(); got != want {
+		t.Errorf("Vacuum() = %d; want %d", got, want)
+	}
+	if h.Tombstones() != 0 || len(h.Nodes) != len(vectors)-want {
+		t.Fatalf("after Vacuum: %d tombstones, %d nodes", h.Tombstones(), len(h.Nodes))
+	}
+
+	for id, node := range h.Nodes {
+		for level, l := range node.Levels {
+			for _, conn := range l.Connections {
+				if h.Nodes[conn.ID] != conn {
+					t.Fatalf("node %d level %d still links to vacuumed node %d", id, level, conn.ID)
+				}
+			}
+		}
+	}
+
+	config := SearchConfig{UseParallel: false, Ef: 64}
+	for i, v := range vectors {
+		if i%3 == 0 {
+			continue
+		}
+		if results := search(t, h, v, 1, config); len(results) != 1 || results[0] != i {
+			t.Errorf("Search(%v) = %v; want [%d]", v, results, i)
+		}
+	}
+
+	if got := h.Vacuum(); got != 0 {
+		t.Errorf("second Vacuum() = %d; want 0", got)
+	}
+}
+
+func TestAutoVacuum(t *testing.T) {
+	h, _ := buildTombstoneIndex(100)
+	h.SetAutoVacuum(0.1)
+
+	for i := 0; i < 20; i++ {
+		h.Delete(i)
+	}
+
+	// Deletes racing the background run may leave a few tombstones, but
+	// never more than the ratio allows
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mutex.RLock()
+		nodes, tombstones := len(h.Nodes), len(h.deletedNodes)
+		h.mutex.RUnlock()
+		if !h.vacuuming.Load() && float64(tombstones) <= 0.1*float64(nodes) {
+			if nodes == 100 || nodes-tombstones != 80 {
+				t.Errorf("got %d nodes and %d tombstones after auto vacuum, want 80 live nodes", nodes, tombstones)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("auto vacuum did not run, %d tombstones left", tombstones)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSaveLoadTombstones(t *testing.T) {
+	filename := "test_tombstones.hnsw"
+	defer os.Remove(filename)
+
+	h1, _ := buildTombstoneIndex(50)
+	h1.SetAutoVacuum(0.5)
+	h1.Delete(7)
+	if err := h1.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	h2, err := Load(filename, Euclidean)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if h2.Tombstones() != 1 {
+		t.Fatalf("Tombstones() = %d after load; want 1", h2.Tombstones())
+	}
+	if h2.deleteMode != DeleteTombstone || h2.vacuumRatio != 0.5 {
+		t.Errorf("after load: delete mode %v, vacuum ratio %v; want tombstones and 0.5", h2.deleteMode, h2.vacuumRatio)
+	}
+	if got := h2.Vacuum(); got != 1 {
+		t.Errorf("Vacuum() after load = %d; want 1", got)
+	}
+}